@@ -42,6 +42,19 @@ type IanaClaims struct {
 	Jti string `json:"jti,omitempty"`
 }
 
+// RegisteredClaims is implemented by claims types embedding IanaClaims,
+// giving access to the registered claims.
+type RegisteredClaims interface {
+	Claims
+	Registered() *IanaClaims
+}
+
+// Registered returns the registered claims, promoted to the claims types
+// embedding IanaClaims.
+func (c *IanaClaims) Registered() *IanaClaims {
+	return c
+}
+
 func (c *IanaClaims) VerifyIssuer(cmp string) bool {
 	return VerifyIss(c.Issuer, cmp)
 }
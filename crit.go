@@ -0,0 +1,63 @@
+package gojwt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// registeredHeaders are the header parameters defined by RFC 7515, RFC 7516
+// and RFC 7518, which must not be listed in "crit".
+var registeredHeaders = map[string]bool{
+	"alg": true, "jku": true, "jwk": true, "kid": true, "x5u": true,
+	"x5c": true, "x5t": true, "x5t#S256": true, "typ": true, "cty": true,
+	"crit": true, "enc": true, "zip": true, "epk": true, "apu": true,
+	"apv": true, "iv": true, "tag": true, "p2s": true, "p2c": true,
+}
+
+// builtinCriticalHeaders are the extensions always understood
+var builtinCriticalHeaders = []string{
+	"b64", // RFC 7797
+}
+
+// checkCritical processes the "crit" header: it must be a non-empty list
+// of extensions present in the header, all of which are either built in or
+// listed in understood. Any other extension is rejected as required by
+// RFC 7515 section 4.1.11.
+func checkCritical(header map[string]interface{}, understood []string) error {
+	v, ok := header["crit"]
+	if !ok {
+		return nil
+	}
+
+	crit, ok := v.([]interface{})
+	if !ok || len(crit) == 0 {
+		return errors.New("Invalid crit header")
+	}
+
+	for _, v := range crit {
+		name, ok := v.(string)
+		if !ok || name == "" {
+			return errors.New("Invalid crit header")
+		}
+		if registeredHeaders[name] {
+			return fmt.Errorf("Registered header parameter %q listed in crit", name)
+		}
+		if !containsString(builtinCriticalHeaders, name) && !containsString(understood, name) {
+			return fmt.Errorf("Unsupported critical header parameter %q", name)
+		}
+		if _, ok := header[name]; !ok {
+			return fmt.Errorf("Critical header parameter %q is missing", name)
+		}
+	}
+
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package gojwt
+
+import "testing"
+
+func TestCriticalHeader(t *testing.T) {
+	sign := func(header map[string]interface{}) string {
+		token := NewToken(HS256, &IanaClaims{})
+		for k, v := range header {
+			token.Header[k] = v
+		}
+		return signTestTokenWith(t, token, testHMACKey)
+	}
+
+	unknown := sign(map[string]interface{}{
+		"crit":         []interface{}{"test-ext"},
+		"test-ext":     true,
+		"not-critical": "ignored",
+	})
+	if err := NewToken(HS256, &IanaClaims{}).Parse(unknown, false); err == nil {
+		t.Error("Expected error for unknown critical header")
+	}
+
+	p := &Parser{CriticalHeaders: []string{"test-ext"}}
+	if err := p.Parse(NewToken(HS256, &IanaClaims{}), unknown, false); err != nil {
+		t.Error(err)
+	}
+
+	for i, header := range []map[string]interface{}{
+		{"crit": []interface{}{}},
+		{"crit": "test-ext", "test-ext": true},
+		{"crit": []interface{}{"test-ext"}},
+		{"crit": []interface{}{"alg"}},
+		{"crit": []interface{}{1}},
+	} {
+		if err := p.Parse(NewToken(HS256, &IanaClaims{}), sign(header), false); err == nil {
+			t.Errorf("[%d] Expected error for crit header %v", i, header["crit"])
+		}
+	}
+
+	// Registered header parameters are never accepted in crit
+	kid := sign(map[string]interface{}{"crit": []interface{}{"kid"}, "kid": "1"})
+	p = &Parser{CriticalHeaders: []string{"kid"}}
+	if err := p.Parse(NewToken(HS256, &IanaClaims{}), kid, false); err == nil {
+		t.Error("Expected error for registered header parameter")
+	}
+}
@@ -0,0 +1,109 @@
+package gojwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SignDetached signs the payload bytes and builds a compact token with a
+// detached payload (RFC 7515 appendix F): the payload segment is left
+// empty in Value, "header..signature", and the payload is transmitted out
+// of band, e.g. as the body of a webhook request. Payload is not used.
+// The payload is signed unencoded when SetUnencodedPayload was called.
+func (t *Token) SignDetached(payload []byte, key interface{}) error {
+	if err := t.setKeyID(key); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(t.Header)
+	if err != nil {
+		return err
+	}
+	header := EncodeSegment(b)
+
+	seg, err := payloadSegment(t.Header, payload)
+	if err != nil {
+		return err
+	}
+
+	t.HeaderPayload = header + "." + seg
+	t.Signature, err = t.Method.Sign(t.HeaderPayload, key)
+	if err != nil {
+		return err
+	}
+
+	t.Value = header + ".." + t.Signature
+	return nil
+}
+
+// VerifyDetached verifies a token with a detached payload against the
+// payload bytes received out of band. The "alg" header must match the
+// signing algorithm the token was created with. Unencoded payloads are
+// detected from the "b64" header.
+func (t *Token) VerifyDetached(tokenString string, payload []byte, key interface{}) error {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return errors.New("The token does not have 3 segments")
+	}
+	if parts[1] != "" {
+		return errors.New("The token payload is not detached")
+	}
+
+	header, err := decodeHeader(parts[0])
+	if err != nil {
+		return err
+	}
+	if err := t.checkAlg(header); err != nil {
+		return err
+	}
+
+	seg, err := payloadSegment(header, payload)
+	if err != nil {
+		return err
+	}
+
+	t.Header = header
+	t.Signature = parts[2]
+	t.HeaderPayload = parts[0] + "." + seg
+	t.Value = tokenString
+
+	if err := t.Verify(key); err != nil {
+		return &TokenError{Text: err, Flags: ErrorIvalidSignature}
+	}
+	return nil
+}
+
+// checkAlg checks the "alg" of a received header against the one the
+// token was created with.
+func (t *Token) checkAlg(header map[string]interface{}) error {
+	if t.Method == nil {
+		return errors.New("Missing signing method")
+	}
+
+	alg, _ := header["alg"].(string)
+	if want, ok := t.Header["alg"].(string); ok && want != alg {
+		return fmt.Errorf("Unexpected signing algorithm in the header: %q", alg)
+	}
+	if lookupSignMethod(alg) == nil {
+		return errors.New("Unsupported signing algorithm in the header")
+	}
+	return nil
+}
+
+func decodeHeader(seg string) (map[string]interface{}, error) {
+	b, err := DecodeSegment(seg)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid algorithm and token type segment: %v", err)
+	}
+
+	var header map[string]interface{}
+	if err = json.Unmarshal(b, &header); err != nil {
+		return nil, fmt.Errorf("Unable to unmarshal header json data: %v", err)
+	}
+	if err = checkCritical(header, nil); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
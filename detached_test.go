@@ -0,0 +1,55 @@
+package gojwt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyDetached(t *testing.T) {
+	// RFC 7515 appendix F, using the example of appendix A.1
+	key, _ := DecodeSegment("AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow")
+	payload, _ := DecodeSegment("eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ")
+	tokenString := "eyJ0eXAiOiJKV1QiLA0KICJhbGciOiJIUzI1NiJ9..dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+	token := NewToken(HS256, nil)
+	if err := token.VerifyDetached(tokenString, payload, key); err != nil {
+		t.Error(err)
+	}
+
+	payload[0] = ' '
+	if err := token.VerifyDetached(tokenString, payload, key); err == nil {
+		t.Error("Expected error for modified payload")
+	}
+}
+
+func TestSignDetached(t *testing.T) {
+	key, err := ParseRSAPrivateKey([]byte(testVectorRSA[0].pemData), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte(`{"event":"payment.created","id":42}`)
+
+	token := NewToken(RS256, nil)
+	if err := token.SignDetached(payload, key); err != nil {
+		t.Fatal(err)
+	}
+	if parts := strings.Split(token.Value, "."); len(parts) != 3 || parts[1] != "" {
+		t.Errorf("got '%v' want detached payload", token.Value)
+	}
+
+	if err := NewToken(RS256, nil).VerifyDetached(token.Value, payload, &key.PublicKey); err != nil {
+		t.Error(err)
+	}
+	if err := NewToken(PS256, nil).VerifyDetached(token.Value, payload, &key.PublicKey); err == nil {
+		t.Error("Expected error for unexpected signing algorithm")
+	}
+
+	// An attached payload is not accepted
+	attached := NewToken(RS256, &IanaClaims{})
+	if err := attached.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewToken(RS256, nil).VerifyDetached(attached.Value, payload, &key.PublicKey); err == nil {
+		t.Error("Expected error for attached payload")
+	}
+}
@@ -10,6 +10,8 @@ const (
 	ErrorInvalidJti               // "jti" (JWT ID)
 	ErrorInvalidClaim             // Generic error
 	ErrorIvalidSignature          // Invalid signature
+	ErrorTokenRevoked             // Revoked token
+	ErrorTokenReplayed            // One-time token presented again
 )
 
 type TokenError struct {
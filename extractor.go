@@ -0,0 +1,141 @@
+package gojwt
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrNoToken is returned by extractors when the request carries no token.
+var ErrNoToken = errors.New("No token found in the request")
+
+// Extractor extracts the token string from a request. It returns
+// ErrNoToken when the request has no token, and another error when the
+// token is present but malformed.
+type Extractor interface {
+	Extract(r *http.Request) (string, error)
+}
+
+// ExtractorFunc adapts a function to the Extractor interface.
+type ExtractorFunc func(r *http.Request) (string, error)
+
+func (f ExtractorFunc) Extract(r *http.Request) (string, error) {
+	return f(r)
+}
+
+// BearerExtractor extracts the token from the "Authorization: Bearer"
+// header of RFC 6750 section 2.1. The scheme is matched
+// case-insensitively and the token must be a b64token; repeated headers
+// and malformed tokens are rejected. Other schemes carry no bearer token
+// and yield ErrNoToken.
+var BearerExtractor Extractor = ExtractorFunc(func(r *http.Request) (string, error) {
+	values := r.Header["Authorization"]
+	if len(values) == 0 || values[0] == "" {
+		return "", ErrNoToken
+	}
+	if len(values) > 1 {
+		return "", errors.New("Multiple Authorization headers")
+	}
+
+	return ParseBearer(values[0])
+})
+
+// ParseBearer returns the token of an Authorization header value using the
+// Bearer scheme, "Bearer" 1*SP b64token. ErrNoToken is returned for other
+// schemes, which RFC 6750 section 3 answers with a bare challenge.
+func ParseBearer(auth string) (string, error) {
+	scheme, token, _ := strings.Cut(auth, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", ErrNoToken
+	}
+
+	token = strings.TrimLeft(token, " ")
+	if !isB64Token(token) {
+		return "", errors.New("Malformed bearer token")
+	}
+	return token, nil
+}
+
+// isB64Token reports whether s matches
+// 1*( ALPHA / DIGIT / "-" / "." / "_" / "~" / "+" / "/" ) *"="
+func isB64Token(s string) bool {
+	s = strings.TrimRight(s, "=")
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~', c == '+', c == '/':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// HeaderExtractor extracts the token from the named request header, used
+// as is.
+type HeaderExtractor string
+
+func (e HeaderExtractor) Extract(r *http.Request) (string, error) {
+	return singleValue(r.Header[http.CanonicalHeaderKey(string(e))], "header")
+}
+
+// CookieExtractor extracts the token from the named cookie.
+type CookieExtractor string
+
+func (e CookieExtractor) Extract(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(string(e))
+	if err != nil || cookie.Value == "" {
+		return "", ErrNoToken
+	}
+	return cookie.Value, nil
+}
+
+// QueryExtractor extracts the token from the named query parameter, e.g.
+// "access_token" for websocket upgrades where headers cannot be set
+// (RFC 6750 section 2.3).
+type QueryExtractor string
+
+func (e QueryExtractor) Extract(r *http.Request) (string, error) {
+	return singleValue(r.URL.Query()[string(e)], "query parameter")
+}
+
+// FormExtractor extracts the token from the named parameter of a form
+// encoded request body (RFC 6750 section 2.2).
+type FormExtractor string
+
+func (e FormExtractor) Extract(r *http.Request) (string, error) {
+	if err := r.ParseForm(); err != nil {
+		return "", err
+	}
+	return singleValue(r.PostForm[string(e)], "form parameter")
+}
+
+// MultiExtractor tries each extractor in order and returns the first token
+// found. A malformed token stops the search, while a request without a
+// token, including one using another Authorization scheme, moves on to the
+// next extractor.
+type MultiExtractor []Extractor
+
+func (e MultiExtractor) Extract(r *http.Request) (string, error) {
+	for _, extractor := range e {
+		token, err := extractor.Extract(r)
+		if err != ErrNoToken {
+			return token, err
+		}
+	}
+	return "", ErrNoToken
+}
+
+func singleValue(values []string, source string) (string, error) {
+	switch {
+	case len(values) == 0 || values[0] == "":
+		return "", ErrNoToken
+	case len(values) > 1:
+		return "", errors.New("Multiple tokens in " + source)
+	}
+	return strings.TrimSpace(values[0]), nil
+}
@@ -0,0 +1,116 @@
+// Package grpcjwt provides gRPC server interceptors authenticating calls
+// with bearer tokens verified by gojwt.
+package grpcjwt
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sdev75/gojwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Authenticator verifies the token found in the "authorization" metadata
+// of incoming calls, "Bearer <token>", and stores the verified token in the
+// context of the handler, see gojwt.FromContext.
+type Authenticator struct {
+	gojwt.Verifier
+
+	// MetadataKey replaces the "authorization" metadata key. The value is
+	// then the token itself, without scheme.
+	MetadataKey string
+
+	// ErrorCode maps a verification error to the status code of the
+	// rejected call. Defaults to ErrorCode.
+	ErrorCode func(err error) codes.Code
+}
+
+// UnaryServerInterceptor returns an interceptor authenticating unary
+// calls.
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.Authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor authenticating streaming
+// calls.
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.Authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// Authenticate verifies the token of the incoming call and returns the
+// context carrying it. Errors are gRPC status errors.
+func (a *Authenticator) Authenticate(ctx context.Context) (context.Context, error) {
+	tokenString, err := a.extract(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	token, err := a.Verify(tokenString)
+	if err != nil {
+		code := ErrorCode(err)
+		if a.ErrorCode != nil {
+			code = a.ErrorCode(err)
+		}
+		return nil, status.Error(code, err.Error())
+	}
+
+	return gojwt.NewContext(ctx, token), nil
+}
+
+func (a *Authenticator) extract(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	key := a.MetadataKey
+	if key == "" {
+		key = "authorization"
+	}
+	values := md.Get(key)
+	switch {
+	case len(values) == 0 || values[0] == "":
+		return "", gojwt.ErrNoToken
+	case len(values) > 1:
+		return "", errors.New("Multiple tokens in metadata")
+	}
+
+	if a.MetadataKey != "" {
+		return values[0], nil
+	}
+	return gojwt.ParseBearer(values[0])
+}
+
+// ErrorCode maps the TokenError flags of a verification error: tokens
+// valid but not meant for this service, failing only on their audience or
+// another claim, yield PermissionDenied. All other errors, including
+// expired, revoked or replayed tokens also meant for another audience,
+// yield Unauthenticated.
+func ErrorCode(err error) codes.Code {
+	const denied = gojwt.ErrorInvalidAudience | gojwt.ErrorInvalidClaim
+	if e, ok := err.(*gojwt.TokenError); ok && e.Flags != 0 && e.Flags&^denied == 0 {
+		return codes.PermissionDenied
+	}
+	return codes.Unauthenticated
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
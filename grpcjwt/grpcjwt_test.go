@@ -0,0 +1,186 @@
+package grpcjwt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sdev75/gojwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var testKey = []byte("01234567890123456789012345678901")
+
+type audienceClaims struct {
+	gojwt.IanaClaims
+}
+
+func (c *audienceClaims) Valid() error {
+	if err := c.IanaClaims.Valid(); err != nil {
+		return err
+	}
+	if !c.VerifyAudience("health") {
+		return &gojwt.TokenError{Text: errors.New("Invalid audience"), Flags: gojwt.ErrorInvalidAudience}
+	}
+	return nil
+}
+
+// healthServer records the subject of the authenticated caller
+type healthServer struct {
+	*health.Server
+	subjects chan string
+}
+
+func (s *healthServer) record(ctx context.Context) {
+	token, ok := gojwt.FromContext(ctx)
+	if !ok {
+		s.subjects <- ""
+		return
+	}
+	s.subjects <- token.Payload.(*audienceClaims).Subject
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	s.record(ctx)
+	return s.Server.Check(ctx, req)
+}
+
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	s.record(stream.Context())
+	return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+func newTestClient(t *testing.T) (healthpb.HealthClient, *healthServer) {
+	a := &Authenticator{
+		Verifier: gojwt.Verifier{
+			Method:    gojwt.HS256,
+			KeyFunc:   func(*gojwt.Token) (interface{}, error) { return testKey, nil },
+			NewClaims: func() gojwt.Claims { return &audienceClaims{} },
+		},
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(a.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(a.StreamServerInterceptor()),
+	)
+	hs := &healthServer{Server: health.NewServer(), subjects: make(chan string, 1)}
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return healthpb.NewHealthClient(conn), hs
+}
+
+func sign(t *testing.T, claims gojwt.Claims) string {
+	token := gojwt.NewToken(gojwt.HS256, claims)
+	if err := token.Sign(testKey); err != nil {
+		t.Fatal(err)
+	}
+	return token.Value
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	client, hs := newTestClient(t)
+
+	for i, data := range []struct {
+		auth     string
+		wantCode codes.Code
+	}{
+		{"Bearer " + sign(t, &gojwt.IanaClaims{Subject: "alice", Audience: "health"}), codes.OK},
+		{"", codes.Unauthenticated},
+		{"Basic YWxpY2U6c2VjcmV0", codes.Unauthenticated},
+		{"Bearer " + sign(t, &gojwt.IanaClaims{Audience: "billing"}), codes.PermissionDenied},
+		{"Bearer " + sign(t, &gojwt.IanaClaims{Audience: "health",
+			ExpiresAt: time.Now().Add(-time.Minute).Unix()}), codes.Unauthenticated},
+		{"Bearer " + sign(t, &gojwt.IanaClaims{Audience: "health"})[:60], codes.Unauthenticated},
+	} {
+		ctx := context.Background()
+		if data.auth != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", data.auth)
+		}
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		if got := status.Code(err); got != data.wantCode {
+			t.Errorf("[%d] got '%v' want '%v' (%v)", i, got, data.wantCode, err)
+		}
+		if data.wantCode == codes.OK {
+			if got := <-hs.subjects; got != "alice" {
+				t.Errorf("[%d] got '%v' want '%v'", i, got, "alice")
+			}
+		}
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	for i, data := range []struct {
+		flags    uint32
+		wantCode codes.Code
+	}{
+		{gojwt.ErrorInvalidAudience, codes.PermissionDenied},
+		{gojwt.ErrorInvalidClaim, codes.PermissionDenied},
+		{gojwt.ErrorInvalidAudience | gojwt.ErrorInvalidClaim, codes.PermissionDenied},
+		{0, codes.Unauthenticated},
+		{gojwt.ErrorInvalidExpiration, codes.Unauthenticated},
+		{gojwt.ErrorInvalidAudience | gojwt.ErrorInvalidExpiration, codes.Unauthenticated},
+		{gojwt.ErrorInvalidAudience | gojwt.ErrorInvalidNotBefore, codes.Unauthenticated},
+		{gojwt.ErrorInvalidAudience | gojwt.ErrorInvalidIssuedAt, codes.Unauthenticated},
+		{gojwt.ErrorInvalidAudience | gojwt.ErrorTokenRevoked, codes.Unauthenticated},
+		{gojwt.ErrorInvalidAudience | gojwt.ErrorTokenReplayed, codes.Unauthenticated},
+		{gojwt.ErrorInvalidAudience | gojwt.ErrorIvalidSignature, codes.Unauthenticated},
+		{gojwt.ErrorInvalidClaim | gojwt.ErrorInvalidToken, codes.Unauthenticated},
+	} {
+		err := &gojwt.TokenError{Text: errors.New("Invalid token"), Flags: data.flags}
+		if got := ErrorCode(err); got != data.wantCode {
+			t.Errorf("[%d] got '%v' want '%v'", i, got, data.wantCode)
+		}
+	}
+
+	if got := ErrorCode(errors.New("Invalid token")); got != codes.Unauthenticated {
+		t.Errorf("got '%v' want '%v'", got, codes.Unauthenticated)
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	client, hs := newTestClient(t)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization",
+		"Bearer "+sign(t, &gojwt.IanaClaims{Subject: "bob", Audience: "health"}))
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-hs.subjects; got != "bob" {
+		t.Errorf("got '%v' want '%v'", got, "bob")
+	}
+
+	stream, err = client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("got '%v' want '%v'", status.Code(err), codes.Unauthenticated)
+	}
+}
@@ -10,15 +10,24 @@ import (
 
 type SignMethodHMAC struct {
 	Hash crypto.Hash
+
+	// AllowWeakKeys disables the minimum key length check, for
+	// interoperability with legacy secrets shorter than the hash output.
+	AllowWeakKeys bool
 }
 
 func (m SignMethodHMAC) Verify(signingString string, signature string, key interface{}) error {
+	secret, err := checkHMACKey(m.Hash, key, m.AllowWeakKeys)
+	if err != nil {
+		return err
+	}
+
 	sig, err := DecodeSegment(signature)
 	if err != nil {
 		return err
 	}
 
-	h := hmac.New(m.Hash.New, key.([]byte))
+	h := hmac.New(m.Hash.New, secret)
 	h.Write([]byte(signingString))
 	if !hmac.Equal(sig, h.Sum(nil)) {
 		return errors.New("Signature mismatch")
@@ -28,7 +37,12 @@ func (m SignMethodHMAC) Verify(signingString string, signature string, key inter
 }
 
 func (m SignMethodHMAC) Sign(signinString string, key interface{}) (string, error) {
-	h := hmac.New(m.Hash.New, key.([]byte))
+	secret, err := checkHMACKey(m.Hash, key, m.AllowWeakKeys)
+	if err != nil {
+		return "", err
+	}
+
+	h := hmac.New(m.Hash.New, secret)
 	h.Write([]byte(signinString))
 	res := strings.TrimRight(
 		base64.URLEncoding.EncodeToString(h.Sum(nil)), "=")
@@ -0,0 +1,79 @@
+package gojwt
+
+import "time"
+
+// Issuer signs tokens after filling in their registered claims. Claims
+// already set by the caller are kept.
+type Issuer struct {
+	// Method is the signing method (HS256, RS256, ...) and Key the
+	// signing key.
+	Method uint
+	Key    interface{}
+
+	// Name is the "iss" claim.
+	Name string
+
+	// Lifetime sets "exp", one hour after "iat" when zero.
+	Lifetime time.Duration
+
+	// NotBeforeSkew sets "nbf" that long before "iat", tolerating clock
+	// skew between the issuer and the verifiers. Zero sets "nbf" to
+	// "iat".
+	NotBeforeSkew time.Duration
+
+	// JTI generates the "jti" claim, RandomJTI when nil.
+	JTI JTIGenerator
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// Issue fills in the registered claims of any claims type embedding
+// IanaClaims and returns the signed compact token.
+func (i *Issuer) Issue(claims RegisteredClaims) (string, error) {
+	if err := i.fill(claims.Registered()); err != nil {
+		return "", err
+	}
+
+	token := NewToken(i.Method, claims)
+	if err := token.Sign(i.Key); err != nil {
+		return "", err
+	}
+	return token.Value, nil
+}
+
+func (i *Issuer) fill(c *IanaClaims) error {
+	now := time.Now()
+	if i.now != nil {
+		now = i.now()
+	}
+
+	if c.Issuer == "" {
+		c.Issuer = i.Name
+	}
+	if c.IssuedAt == 0 {
+		c.IssuedAt = now.Unix()
+	}
+	if c.NotBefore == 0 {
+		c.NotBefore = now.Add(-i.NotBeforeSkew).Unix()
+	}
+	if c.ExpiresAt == 0 {
+		lifetime := i.Lifetime
+		if lifetime == 0 {
+			lifetime = time.Hour
+		}
+		c.ExpiresAt = now.Add(lifetime).Unix()
+	}
+	if c.Jti == "" {
+		jti := i.JTI
+		if jti == nil {
+			jti = RandomJTI
+		}
+		id, err := jti()
+		if err != nil {
+			return err
+		}
+		c.Jti = id
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package gojwt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIssuer(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	i := &Issuer{
+		Method:        HS256,
+		Key:           testHMACKey,
+		Name:          "auth",
+		Lifetime:      10 * time.Minute,
+		NotBeforeSkew: 30 * time.Second,
+		JTI:           func() (string, error) { return "id-1", nil },
+		now:           func() time.Time { return now },
+	}
+
+	tokenString, err := i.Issue(&claimsRS{Admin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := &claimsRS{}
+	token := NewToken(HS256, claims)
+	if err := token.Parse(tokenString, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := token.Verify(testHMACKey); err != nil {
+		t.Error(err)
+	}
+
+	want := IanaClaims{
+		Issuer:    "auth",
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix() - 30,
+		ExpiresAt: now.Unix() + 600,
+		Jti:       "id-1",
+	}
+	if claims.IanaClaims != want || !claims.Admin {
+		t.Errorf("got '%+v' want '%+v'", claims.IanaClaims, want)
+	}
+
+	// Claims set by the caller are kept
+	custom := &IanaClaims{Subject: "alice", ExpiresAt: now.Unix() + 60, Jti: "custom"}
+	if _, err := i.Issue(custom); err != nil {
+		t.Fatal(err)
+	}
+	if custom.ExpiresAt != now.Unix()+60 || custom.Jti != "custom" || custom.Issuer != "auth" {
+		t.Errorf("got '%+v'", custom)
+	}
+
+	// Random jti by default
+	i.JTI = nil
+	a, b := &IanaClaims{}, &IanaClaims{}
+	i.Issue(a)
+	i.Issue(b)
+	if a.Jti == "" || a.Jti == b.Jti {
+		t.Errorf("got '%v' '%v' want distinct jti", a.Jti, b.Jti)
+	}
+}
@@ -0,0 +1,143 @@
+package gojwt
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// JTIGenerator generates "jti" claim values. The generators below draw
+// their randomness from crypto/rand.
+type JTIGenerator func() (string, error)
+
+// RandomJTI returns a random 128-bit value, base64url encoded in 22
+// characters.
+func RandomJTI() (string, error) {
+	return randomID()
+}
+
+// UUIDv4 returns a random UUID (RFC 9562 section 5.4).
+func UUIDv4() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", err
+	}
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u), nil
+}
+
+var uuidv7 struct {
+	sync.Mutex
+	ms  uint64
+	seq uint16
+}
+
+// UUIDv7 returns a time-ordered UUID (RFC 9562 section 5.7): a millisecond
+// timestamp followed by random bits. Values generated within the same
+// millisecond are ordered by a 12-bit counter (section 6.2 method 1).
+func UUIDv7() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", err
+	}
+
+	uuidv7.Lock()
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	if ms > uuidv7.ms {
+		// The counter starts at a random value leaving room to increment
+		uuidv7.ms = ms
+		uuidv7.seq = binary.BigEndian.Uint16(u[6:8]) & 0x07ff
+	} else if uuidv7.seq++; uuidv7.seq > 0x0fff {
+		uuidv7.ms++
+		uuidv7.seq = binary.BigEndian.Uint16(u[6:8]) & 0x07ff
+	}
+	ms, seq := uuidv7.ms, uuidv7.seq
+	uuidv7.Unlock()
+
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], ms)
+	copy(u[:6], ts[2:])
+	u[6] = 0x70 | byte(seq>>8)
+	u[7] = byte(seq)
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u), nil
+}
+
+func formatUUID(u [16]byte) string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+var ulid struct {
+	sync.Mutex
+	ms      uint64
+	entropy [10]byte
+}
+
+// crockford is the Crockford base32 alphabet used by ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID returns a Universally Unique Lexicographically Sortable Identifier:
+// a 48-bit millisecond timestamp and 80 random bits, encoded in 26
+// characters. Values generated within the same millisecond increment the
+// random part, so that they sort in generation order.
+func ULID() (string, error) {
+	var u [16]byte
+
+	ulid.Lock()
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	if ms > ulid.ms {
+		if _, err := rand.Read(ulid.entropy[:]); err != nil {
+			ulid.Unlock()
+			return "", err
+		}
+		ulid.ms = ms
+	} else {
+		i := len(ulid.entropy) - 1
+		for ; i >= 0; i-- {
+			if ulid.entropy[i]++; ulid.entropy[i] != 0 {
+				break
+			}
+		}
+		if i < 0 {
+			ulid.Unlock()
+			return "", errors.New("ULID random part overflow")
+		}
+	}
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], ulid.ms)
+	copy(u[:6], ts[2:])
+	copy(u[6:], ulid.entropy[:])
+	ulid.Unlock()
+
+	return encodeULID(u), nil
+}
+
+func encodeULID(u [16]byte) string {
+	// 26 characters of 5 bits encode the 128 bits preceded by 2 zero bits
+	var b [26]byte
+	for i := range b {
+		var v byte
+		for bit := i*5 - 2; bit < i*5+3; bit++ {
+			v <<= 1
+			if bit >= 0 && u[bit/8]&(0x80>>uint(bit%8)) != 0 {
+				v |= 1
+			}
+		}
+		b[i] = crockford[v]
+	}
+	return string(b[:])
+}
@@ -0,0 +1,81 @@
+package gojwt
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJTIGenerators(t *testing.T) {
+	for name, data := range map[string]struct {
+		gen    JTIGenerator
+		format *regexp.Regexp
+		sorted bool
+	}{
+		"random": {RandomJTI, regexp.MustCompile(`^[A-Za-z0-9_-]{22}$`), false},
+		"uuidv4": {UUIDv4, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), false},
+		"uuidv7": {UUIDv7, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), true},
+		"ulid":   {ULID, regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`), true},
+	} {
+		const n = 10000
+		seen := make(map[string]bool, n)
+		ids := make([]string, n)
+		for i := range ids {
+			id, err := data.gen()
+			if err != nil {
+				t.Fatalf("[%s] %v", name, err)
+			}
+			if !data.format.MatchString(id) {
+				t.Fatalf("[%s] got '%v' want format '%v'", name, id, data.format)
+			}
+			if seen[id] {
+				t.Fatalf("[%s] Collision on '%v'", name, id)
+			}
+			seen[id] = true
+			ids[i] = id
+		}
+
+		// Time-ordered identifiers sort in generation order
+		if data.sorted && !sort.StringsAreSorted(ids) {
+			t.Errorf("[%s] Identifiers are not sorted", name)
+		}
+	}
+}
+
+func TestJTITimestamp(t *testing.T) {
+	before := time.Now().UnixNano() / int64(time.Millisecond)
+
+	id, _ := UUIDv7()
+	ms, err := strconv.ParseInt(id[0:8]+id[9:13], 16, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ms < before || ms > before+1000 {
+		t.Errorf("got '%v' want about '%v'", ms, before)
+	}
+
+	id, _ = ULID()
+	ms = 0
+	for _, c := range id[:10] {
+		ms = ms<<5 | int64(strings.IndexRune(crockford, c))
+	}
+	if ms < before || ms > before+1000 {
+		t.Errorf("got '%v' want about '%v'", ms, before)
+	}
+}
+
+func TestEncodeULID(t *testing.T) {
+	var u [16]byte
+	if got := encodeULID(u); got != "00000000000000000000000000" {
+		t.Errorf("got '%v' want '%v'", got, "00000000000000000000000000")
+	}
+	for i := range u {
+		u[i] = 0xff
+	}
+	if got := encodeULID(u); got != "7ZZZZZZZZZZZZZZZZZZZZZZZZZ" {
+		t.Errorf("got '%v' want '%v'", got, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ")
+	}
+}
@@ -0,0 +1,339 @@
+package gojwt
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeyMethod determines the Content Encryption Key (CEK) of an encrypted
+// token, identified by the "alg" header (RFC 7516 section 4.1.1).
+type KeyMethod interface {
+	// EncryptKey returns the CEK for the content encryption method and
+	// its encrypted form for the recipient. Header parameters required
+	// by the algorithm are added to header.
+	EncryptKey(key interface{}, enc EncryptMethod, header map[string]interface{}) ([]byte, []byte, error)
+
+	// DecryptKey recovers the CEK from the encrypted key.
+	DecryptKey(key interface{}, enc EncryptMethod, header map[string]interface{}, encryptedKey []byte) ([]byte, error)
+}
+
+// EncryptMethod performs the authenticated encryption of the plaintext
+// with the CEK, identified by the "enc" header (RFC 7516 section 4.1.2).
+type EncryptMethod interface {
+	// KeySize returns the length of the CEK in bytes.
+	KeySize() int
+
+	// Encrypt returns the initialization vector, the ciphertext and the
+	// authentication tag.
+	Encrypt(cek, plaintext, aad []byte) ([]byte, []byte, []byte, error)
+
+	Decrypt(cek, iv, ciphertext, tag, aad []byte) ([]byte, error)
+}
+
+type KeyMethodData struct {
+	Method KeyMethod
+	Alg    string
+}
+
+type EncryptMethodData struct {
+	Method EncryptMethod
+	Enc    string
+}
+
+// Key management algorithms
+const (
+	DIR uint = 1 + iota
+	A128KW
+	A256KW
+	RSA_OAEP
+	RSA_OAEP_256
+	ECDH_ES
+	ECDH_ES_A128KW
+	ECDH_ES_A256KW
+	PBES2_HS256_A128KW
+	PBES2_HS512_A256KW
+)
+
+// Content encryption algorithms
+const (
+	A128GCM uint = 1 + iota
+	A256GCM
+	A128CBC_HS256
+	A256CBC_HS512
+)
+
+var (
+	KeyMethodTable = []KeyMethodData{
+		DIR: KeyMethodData{
+			Method: KeyMethodDirect{},
+			Alg:    "dir",
+		},
+		A128KW: KeyMethodData{
+			Method: KeyMethodAESKW{KeyLen: 16},
+			Alg:    "A128KW",
+		},
+		A256KW: KeyMethodData{
+			Method: KeyMethodAESKW{KeyLen: 32},
+			Alg:    "A256KW",
+		},
+		RSA_OAEP: KeyMethodData{
+			Method: KeyMethodRSAOAEP{Hash: crypto.SHA1},
+			Alg:    "RSA-OAEP",
+		},
+		RSA_OAEP_256: KeyMethodData{
+			Method: KeyMethodRSAOAEP{Hash: crypto.SHA256},
+			Alg:    "RSA-OAEP-256",
+		},
+		ECDH_ES: KeyMethodData{
+			Method: KeyMethodECDHES{},
+			Alg:    "ECDH-ES",
+		},
+		ECDH_ES_A128KW: KeyMethodData{
+			Method: KeyMethodECDHES{KeyLen: 16},
+			Alg:    "ECDH-ES+A128KW",
+		},
+		ECDH_ES_A256KW: KeyMethodData{
+			Method: KeyMethodECDHES{KeyLen: 32},
+			Alg:    "ECDH-ES+A256KW",
+		},
+		PBES2_HS256_A128KW: KeyMethodData{
+			Method: KeyMethodPBES2{Hash: crypto.SHA256, KeyLen: 16},
+			Alg:    "PBES2-HS256+A128KW",
+		},
+		PBES2_HS512_A256KW: KeyMethodData{
+			Method: KeyMethodPBES2{Hash: crypto.SHA512, KeyLen: 32},
+			Alg:    "PBES2-HS512+A256KW",
+		},
+	}
+
+	EncryptMethodTable = []EncryptMethodData{
+		A128GCM: EncryptMethodData{
+			Method: EncryptMethodGCM{KeyLen: 16},
+			Enc:    "A128GCM",
+		},
+		A256GCM: EncryptMethodData{
+			Method: EncryptMethodGCM{KeyLen: 32},
+			Enc:    "A256GCM",
+		},
+		A128CBC_HS256: EncryptMethodData{
+			Method: EncryptMethodCBCHMAC{KeyLen: 32, Hash: crypto.SHA256},
+			Enc:    "A128CBC-HS256",
+		},
+		A256CBC_HS512: EncryptMethodData{
+			Method: EncryptMethodCBCHMAC{KeyLen: 64, Hash: crypto.SHA512},
+			Enc:    "A256CBC-HS512",
+		},
+	}
+)
+
+// EncryptedToken is a JWE in compact serialization (RFC 7516), made of
+// five segments: header, encrypted key, initialization vector, ciphertext
+// and authentication tag. The plaintext is the JSON encoded Payload, or
+// Plaintext when no Payload is set.
+type EncryptedToken struct {
+	Value         string
+	KeyMethod     KeyMethod
+	EncryptMethod EncryptMethod
+	Header        map[string]interface{}
+	Payload       Claims
+	Plaintext     []byte
+
+	EncryptedKey []byte
+	IV           []byte
+	Ciphertext   []byte
+	Tag          []byte
+
+	// protected is the encoded header, used as additional authenticated
+	// data
+	protected string
+}
+
+// NewEncryptedToken returns a token encrypted with the key management
+// algorithm alg (DIR, A128KW, ...) and the content encryption algorithm
+// enc (A128GCM, A256GCM, ...).
+func NewEncryptedToken(alg uint, enc uint, claims Claims) *EncryptedToken {
+	return &EncryptedToken{
+		KeyMethod:     KeyMethodTable[alg].Method,
+		EncryptMethod: EncryptMethodTable[enc].Method,
+		Header: map[string]interface{}{
+			"alg": KeyMethodTable[alg].Alg,
+			"enc": EncryptMethodTable[enc].Enc,
+		},
+		Payload: claims,
+	}
+}
+
+// Encrypt encrypts the token for the recipient key and builds Value.
+func (t *EncryptedToken) Encrypt(key interface{}) error {
+	if t.KeyMethod == nil || t.EncryptMethod == nil {
+		return errors.New("Missing key management or content encryption method")
+	}
+
+	plaintext := t.Plaintext
+	if t.Payload != nil {
+		b, err := json.Marshal(t.Payload)
+		if err != nil {
+			return err
+		}
+		plaintext = b
+	}
+
+	cek, encryptedKey, err := t.KeyMethod.EncryptKey(key, t.EncryptMethod, t.Header)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(t.Header)
+	if err != nil {
+		return err
+	}
+	t.protected = EncodeSegment(b)
+
+	iv, ciphertext, tag, err := t.EncryptMethod.Encrypt(cek, plaintext, []byte(t.protected))
+	if err != nil {
+		return err
+	}
+
+	t.Plaintext = plaintext
+	t.EncryptedKey = encryptedKey
+	t.IV = iv
+	t.Ciphertext = ciphertext
+	t.Tag = tag
+	t.Value = strings.Join([]string{
+		t.protected,
+		EncodeSegment(encryptedKey),
+		EncodeSegment(iv),
+		EncodeSegment(ciphertext),
+		EncodeSegment(tag),
+	}, ".")
+	return nil
+}
+
+// Parse decodes the five segments of the token. The key management and
+// content encryption algorithms in the header must match the ones the
+// token was created with; when the token has none they are looked up from
+// the header, except PBES2 which is costly to derive and must be selected
+// by the caller. The content is decrypted separately with Decrypt, so that
+// the header can be inspected to select the key.
+func (t *EncryptedToken) Parse(tokenString string) error {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 5 {
+		return errors.New("The token does not have 5 segments")
+	}
+
+	seg, err := DecodeSegment(parts[0])
+	if err != nil {
+		return fmt.Errorf("Invalid header segment: %v", err)
+	}
+
+	var header map[string]interface{}
+	if err = json.Unmarshal(seg, &header); err != nil {
+		return fmt.Errorf("Unable to unmarshal header json data: %v", err)
+	}
+	if err = checkCritical(header, nil); err != nil {
+		return err
+	}
+
+	if _, ok := header["zip"]; ok {
+		return errors.New("Compressed tokens are not supported")
+	}
+
+	alg, _ := header["alg"].(string)
+	enc, _ := header["enc"].(string)
+	if t.KeyMethod == nil {
+		method := lookupKeyMethod(alg)
+		if method == nil {
+			return errors.New("Unsupported key management algorithm in the header")
+		}
+		if _, ok := method.(KeyMethodPBES2); ok {
+			return errors.New("PBES2 key management must be selected explicitly")
+		}
+		t.KeyMethod = method
+	} else if want, _ := t.Header["alg"].(string); want != alg {
+		return fmt.Errorf("Unexpected key management algorithm in the header: %q", alg)
+	}
+	if t.EncryptMethod == nil {
+		if t.EncryptMethod = lookupEncryptMethod(enc); t.EncryptMethod == nil {
+			return errors.New("Unsupported content encryption algorithm in the header")
+		}
+	} else if want, _ := t.Header["enc"].(string); want != enc {
+		return fmt.Errorf("Unexpected content encryption algorithm in the header: %q", enc)
+	}
+
+	segs := make([][]byte, 4)
+	for i, part := range parts[1:] {
+		if segs[i], err = DecodeSegment(part); err != nil {
+			return fmt.Errorf("Invalid token segment: %v", err)
+		}
+	}
+
+	t.Header = header
+	t.protected = parts[0]
+	t.EncryptedKey = segs[0]
+	t.IV = segs[1]
+	t.Ciphertext = segs[2]
+	t.Tag = segs[3]
+	t.Plaintext = nil
+	t.Value = tokenString
+	return nil
+}
+
+// Decrypt decrypts the parsed token with the recipient key and decodes the
+// plaintext into Payload, unless the token contains a nested JWT.
+func (t *EncryptedToken) Decrypt(key interface{}) error {
+	if t.protected == "" {
+		return errors.New("The token has not been parsed")
+	}
+
+	cek, err := t.KeyMethod.DecryptKey(key, t.EncryptMethod, t.Header, t.EncryptedKey)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := t.EncryptMethod.Decrypt(cek, t.IV, t.Ciphertext, t.Tag, []byte(t.protected))
+	if err != nil {
+		return err
+	}
+	t.Plaintext = plaintext
+
+	if cty, _ := t.Header["cty"].(string); t.Payload == nil || strings.EqualFold(cty, "JWT") {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewBuffer(plaintext))
+	dec.UseNumber()
+	if err := dec.Decode(&t.Payload); err != nil {
+		return fmt.Errorf("Unable to decode payload data: %v", err)
+	}
+
+	return nil
+}
+
+func (t *EncryptedToken) Validate() error {
+	if t.Payload == nil {
+		return errors.New("The token has no payload")
+	}
+	return t.Payload.Valid()
+}
+
+func lookupKeyMethod(alg string) KeyMethod {
+	for _, data := range KeyMethodTable {
+		if data.Method != nil && data.Alg == alg {
+			return data.Method
+		}
+	}
+	return nil
+}
+
+func lookupEncryptMethod(enc string) EncryptMethod {
+	for _, data := range EncryptMethodTable {
+		if data.Method != nil && data.Enc == enc {
+			return data.Method
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package gojwt
+
+import (
+	"crypto/aes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// KeyMethodDirect implements direct encryption with a shared symmetric key
+// ("dir", RFC 7518 section 4.5). The key is used as the CEK and the
+// encrypted key segment is empty.
+type KeyMethodDirect struct{}
+
+func (m KeyMethodDirect) EncryptKey(key interface{}, enc EncryptMethod, header map[string]interface{}) ([]byte, []byte, error) {
+	cek, ok := key.([]byte)
+	if !ok {
+		return nil, nil, errors.New("Invalid key type, direct encryption requires []byte")
+	}
+	if len(cek) != enc.KeySize() {
+		return nil, nil, errors.New("Invalid key length for the content encryption algorithm")
+	}
+
+	return cek, nil, nil
+}
+
+func (m KeyMethodDirect) DecryptKey(key interface{}, enc EncryptMethod, header map[string]interface{}, encryptedKey []byte) ([]byte, error) {
+	if len(encryptedKey) != 0 {
+		return nil, errors.New("Encrypted key must be empty with direct encryption")
+	}
+
+	cek, _, err := m.EncryptKey(key, enc, header)
+	return cek, err
+}
+
+// KeyMethodAESKW implements AES Key Wrap of a random CEK with a shared
+// symmetric key (A128KW, A256KW, RFC 7518 section 4.4).
+type KeyMethodAESKW struct {
+	KeyLen int
+}
+
+func (m KeyMethodAESKW) EncryptKey(key interface{}, enc EncryptMethod, header map[string]interface{}) ([]byte, []byte, error) {
+	kek, err := m.kek(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cek, err := randomKey(enc.KeySize())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedKey, err := aesKeyWrap(kek, cek)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cek, encryptedKey, nil
+}
+
+func (m KeyMethodAESKW) DecryptKey(key interface{}, enc EncryptMethod, header map[string]interface{}, encryptedKey []byte) ([]byte, error) {
+	kek, err := m.kek(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return aesKeyUnwrap(kek, encryptedKey)
+}
+
+func (m KeyMethodAESKW) kek(key interface{}) ([]byte, error) {
+	kek, ok := key.([]byte)
+	if !ok {
+		return nil, errors.New("Invalid key type, AES key wrap requires []byte")
+	}
+	if len(kek) != m.KeyLen {
+		return nil, errors.New("Invalid key length for AES key wrap")
+	}
+
+	return kek, nil
+}
+
+func randomKey(size int) ([]byte, error) {
+	key := make([]byte, size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// aesKeyWrapIV is the default initial value of RFC 3394 section 2.2.3.1
+var aesKeyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// aesKeyWrap wraps the key with the key encryption key as specified in
+// RFC 3394 section 2.2.1.
+func aesKeyWrap(kek, key []byte) ([]byte, error) {
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, errors.New("Invalid key length for AES key wrap")
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(key) / 8
+	out := make([]byte, 8+len(key))
+	copy(out, aesKeyWrapIV)
+	copy(out[8:], key)
+
+	var buf [16]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf[:8], out[:8])
+			copy(buf[8:], out[i*8:i*8+8])
+			block.Encrypt(buf[:], buf[:])
+
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(out[i*8:i*8+8], buf[8:])
+		}
+	}
+
+	return out, nil
+}
+
+// aesKeyUnwrap unwraps the key with the key encryption key as specified in
+// RFC 3394 section 2.2.2, checking the integrity of the result.
+func aesKeyUnwrap(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, errors.New("Invalid wrapped key length")
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(wrapped)/8 - 1
+	out := make([]byte, len(wrapped))
+	copy(out, wrapped)
+
+	var buf [16]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(out[:8])^t)
+			copy(buf[8:], out[i*8:i*8+8])
+			block.Decrypt(buf[:], buf[:])
+
+			copy(out[:8], buf[:8])
+			copy(out[i*8:i*8+8], buf[8:])
+		}
+	}
+
+	if subtle.ConstantTimeCompare(out[:8], aesKeyWrapIV) != 1 {
+		return nil, errors.New("Failed to unwrap key")
+	}
+
+	return out[8:], nil
+}
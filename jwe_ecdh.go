@@ -0,0 +1,198 @@
+package gojwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// KeyMethodECDHES implements Elliptic Curve Diffie-Hellman Ephemeral Static
+// key agreement (RFC 7518 section 4.6). With a zero KeyLen the agreed key
+// is used directly as the CEK (ECDH-ES), otherwise it is the key
+// encryption key wrapping a random CEK with AES Key Wrap (ECDH-ES+A128KW,
+// ECDH-ES+A256KW). The ephemeral public key is sent in the "epk" header;
+// the optional "apu" and "apv" headers, base64url encoded, are fed to the
+// Concat KDF.
+type KeyMethodECDHES struct {
+	KeyLen int
+}
+
+func (m KeyMethodECDHES) EncryptKey(key interface{}, enc EncryptMethod, header map[string]interface{}) ([]byte, []byte, error) {
+	if k, ok := key.(*ecdsa.PrivateKey); ok {
+		key = &k.PublicKey
+	}
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("Invalid key type %T, ECDH-ES requires *ecdsa.PublicKey", key)
+	}
+	if _, _, err := curveParams(pub); err != nil {
+		return nil, nil, err
+	}
+	if _, err := pub.ECDH(); err != nil {
+		return nil, nil, errors.New("Invalid EC public key")
+	}
+
+	eph, err := ecdsa.GenerateKey(pub.Curve, rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	epk, err := NewJWK(&eph.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	header["epk"] = epk
+
+	derived, err := m.deriveKey(eph, pub, enc, header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if m.KeyLen == 0 {
+		return derived, nil, nil
+	}
+
+	cek, err := randomKey(enc.KeySize())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedKey, err := aesKeyWrap(derived, cek)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cek, encryptedKey, nil
+}
+
+func (m KeyMethodECDHES) DecryptKey(key interface{}, enc EncryptMethod, header map[string]interface{}, encryptedKey []byte) ([]byte, error) {
+	pkey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("Invalid key type %T, ECDH-ES requires *ecdsa.PrivateKey", key)
+	}
+
+	// The header may hold a *JWK or its decoded json form
+	b, err := json.Marshal(header["epk"])
+	if err != nil {
+		return nil, err
+	}
+	var jwk JWK
+	if err := json.Unmarshal(b, &jwk); err != nil || jwk.Kty != "EC" {
+		return nil, errors.New("Missing or invalid epk header")
+	}
+
+	// Key checks the point is on the curve
+	epk, err := jwk.Key()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid epk header: %v", err)
+	}
+	pub, ok := epk.(*ecdsa.PublicKey)
+	if !ok || pub.Curve != pkey.Curve {
+		return nil, errors.New("Invalid epk header curve")
+	}
+
+	derived, err := m.deriveKey(pkey, pub, enc, header)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.KeyLen == 0 {
+		if len(encryptedKey) != 0 {
+			return nil, errors.New("Encrypted key must be empty with direct key agreement")
+		}
+		return derived, nil
+	}
+
+	return aesKeyUnwrap(derived, encryptedKey)
+}
+
+// deriveKey computes the shared secret Z and derives the agreed key with
+// the Concat KDF. The algorithm ID is "enc" for direct key agreement and
+// "alg" otherwise.
+func (m KeyMethodECDHES) deriveKey(priv *ecdsa.PrivateKey, pub *ecdsa.PublicKey, enc EncryptMethod, header map[string]interface{}) ([]byte, error) {
+	algID, _ := header["alg"].(string)
+	keyLen := m.KeyLen
+	if keyLen == 0 {
+		algID, _ = header["enc"].(string)
+		keyLen = enc.KeySize()
+	}
+
+	apu, err := decodeHeaderSegment(header, "apu")
+	if err != nil {
+		return nil, err
+	}
+	apv, err := decodeHeaderSegment(header, "apv")
+	if err != nil {
+		return nil, err
+	}
+
+	// crypto/ecdh returns the x coordinate of the shared point, padded to
+	// the coordinate size as required by RFC 7518 section 4.6.2
+	privECDH, err := priv.ECDH()
+	if err != nil {
+		return nil, errors.New("Invalid EC private key")
+	}
+	pubECDH, err := pub.ECDH()
+	if err != nil {
+		return nil, errors.New("Invalid EC public key")
+	}
+	z, err := privECDH.ECDH(pubECDH)
+	if err != nil {
+		return nil, err
+	}
+
+	return concatKDF(crypto.SHA256, z, []byte(algID), apu, apv, keyLen), nil
+}
+
+// concatKDF implements the Concat KDF of NIST SP 800-56A as profiled by
+// RFC 7518 section 4.6.2, returning keyLen bytes.
+func concatKDF(hash crypto.Hash, z, algID, apu, apv []byte, keyLen int) []byte {
+	otherInfo := make([]byte, 0, 16+len(algID)+len(apu)+len(apv))
+	for _, data := range [][]byte{algID, apu, apv} {
+		otherInfo = appendUint32(otherInfo, uint32(len(data)))
+		otherInfo = append(otherInfo, data...)
+	}
+	otherInfo = appendUint32(otherInfo, uint32(keyLen*8))
+
+	h := hash.New()
+	out := make([]byte, 0, keyLen+h.Size())
+	for counter := uint32(1); len(out) < keyLen; counter++ {
+		h.Reset()
+		h.Write(appendUint32(nil, counter))
+		h.Write(z)
+		h.Write(otherInfo)
+		out = h.Sum(out)
+	}
+
+	return out[:keyLen]
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+// decodeHeaderSegment decodes an optional base64url header parameter.
+func decodeHeaderSegment(header map[string]interface{}, name string) ([]byte, error) {
+	v, ok := header[name]
+	if !ok {
+		return nil, nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("Invalid %s header", name)
+	}
+
+	b, err := DecodeSegment(s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s header: %v", name, err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,183 @@
+package gojwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestConcatKDF(t *testing.T) {
+	// RFC 7518 appendix C, Bob's static key and Alice's ephemeral key
+	var alice, bob JWK
+	json.Unmarshal([]byte(`{"kty":"EC","crv":"P-256",
+		"x":"gI0GAILBdu7T53akrFmMyGcsF3n5dO7MmwNBHKW5SV0",
+		"y":"SLW_xSffzlPWrHEVI30DHM_4egVwt3NQqeUD7nMFpps"}`), &alice)
+	json.Unmarshal([]byte(`{"kty":"EC","crv":"P-256",
+		"x":"weNJy2HscCSM6AEDTDg04biOvhFhyyWvOHQfeF_PxMQ",
+		"y":"e8lnCO-AlStT-NJVX-crhB7QRYhiix03illJOVAOyck",
+		"d":"VEmDZpDXXK8p8N0Cndsxs924q6nS1RXFASRl6BfUqdw"}`), &bob)
+
+	epk, err := alice.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := bob.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := map[string]interface{}{
+		"alg": "ECDH-ES",
+		"enc": "A128GCM",
+		"apu": "QWxpY2U",
+		"apv": "Qm9i",
+	}
+	got, err := KeyMethodECDHES{}.deriveKey(key.(*ecdsa.PrivateKey), epk.(*ecdsa.PublicKey),
+		EncryptMethodTable[A128GCM].Method, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "VqqN6vgjbSBcIijNcacQGg"; EncodeSegment(got) != want {
+		t.Errorf("got '%v' want '%v'", EncodeSegment(got), want)
+	}
+}
+
+// Known-answer vectors of the jose2go test suite
+// (github.com/dvsekhvalnov/jose2go, jose_test.go), produced by an
+// independent implementation.
+var testJWEECDHESVectors = []struct {
+	alg, enc uint
+	jwk      string
+	value    string
+}{
+	{ECDH_ES, A128CBC_HS256, testJWEECDHESKeyP256, `
+			eyJhbGciOiJFQ0RILUVTIiwiZW5jIjoiQTEyOENCQy1IUzI1NiIsImVwayI6eyJrdHkiOiJFQyIsIngiOiItVk1LTG5NeW9IVHRGUlpGNnFXNndkRm5BN21KQkdiNzk4V3FVMFV3QVhZIiwieSI6ImhQQWNReTgzVS01Qjl1U21xbnNXcFZzbHVoZGJSZE1nbnZ0cGdmNVhXTjgiLCJjcnYiOiJQLTI1NiJ9fQ.
+			.
+			UA3N2j-TbYKKD361AxlXUA.
+			XxFur_nY1GauVp5W_KO2DEHfof5s7kUwvOgghiNNNmnB4Vxj5j8VRS8vMOb51nYy2wqmBb2gBf1IHDcKZdACkCOMqMIcpBvhyqbuKiZPLHiilwSgVV6ubIV88X0vK0C8ZPe5lEyRudbgFjdlTnf8TmsvuAsdtPn9dXwDjUR23bD2ocp8UGAV0lKqKzpAw528vTfD0gwMG8gt_op8yZAxqqLLljMuZdTnjofAfsW2Rq3Z6GyLUlxR51DAUlQKi6UpsKMJoXTrm1Jw8sXBHpsRqA.
+			UHCYOtnqk4SfhAknCnymaQ`},
+	{ECDH_ES_A128KW, A128GCM, testJWEECDHESKeyP256, `
+			eyJhbGciOiJFQ0RILUVTK0ExMjhLVyIsImVuYyI6IkExMjhHQ00iLCJlcGsiOnsia3R5IjoiRUMiLCJ4IjoiNnlzVWZVd09vVWxENUpGZG9qUHFXeFd3ZkJ3b2ttWmpOVmxJRFFrcG1PMCIsInkiOiJKZVpia19QazIybWowVFUwcG5uQjNVaUwySzJJcVl6Tk0xVVRPZS1KY3dZIiwiY3J2IjoiUC0yNTYifX0.
+			e1n3YTorJJ-H7eWby-pfGWzVx0aDScCT.
+			VQLnlbAD3N1O-k-S.
+			mJzcAMoxUMQxXIHFGcVjuEVKw70lC6rNbcGqverZBkycPQ2EDgZCiqMgJenHuecvG_YqShi50uZYVyYS4TTrGh1Bj4jP6iFZ8Ksww3hW_jYzKQbp9CdbmOL1f0f25RKwUq61AraXGoJ1Lrs8IM96tvTjKTGpDkNMJ8xN4kVcRcrM5fjTIx973XKo2_nbuCpn-BlAhB6wzYuw_EFsqis8-8cssPENLuGA-n-xX66akqdhycfh5RiqrTPYUnk5ss1Fo_LWWA.
+			l0-CNccSNLTgVdGW1CZr9w`},
+	{ECDH_ES_A256KW, A256GCM, testJWEECDHESKeyP521, `
+			eyJhbGciOiJFQ0RILUVTK0EyNTZLVyIsImVuYyI6IkEyNTZHQ00iLCJlcGsiOnsia3R5IjoiRUMiLCJ4IjoiQUlMTkMzX2lEN0hhSWFhZEU5bGZPcHI5YzBCYzV6cEtraUtkcUNpeGVubHdydlhFVDF4Y0RqbFMtSWZxSUR6VWNoekhiVFgxN0Z4ZGM2X1dOZ2std3VlUyIsInkiOiJBQlFwLWRsalVrZHlOcHFOTkxlSlEyUEZGVmplUlV4SjRGOFZYNGpOMGw4U1Q3Y0NMY0tmVVpKQmJ5b2FNbzhuX3Q1dDc3a1hSOE1aRllOcFhadFBHQTRWIiwiY3J2IjoiUC01MjEifX0.
+			HbaOJoNm7rKdFPeNNxk5NZGWqyleUBrpme7kBfkPSNgStHua5SGqgA.
+			qvi8Wxs7EFXTvDAI.
+			HnPWLNveG0ypTWGWb-V4ORyshMmoR7IhaWUDKkoV9HoD-V6z9WZqAx3uX45Se2fxO9aqSVWqQbrLkw9C9A4DGGiMv27tr29m_c4jUOF_TEGWuhAUAo3Lv-srLFGxQoA3yNWsCpI5VsUVxqc7Sx1dJcEHe8DQcsYTaroNTh0cUMQuqJX9waQm2H-qFjrkLk3d9bo8rhB3x8JTi_X5uNcB1mNH51pPDtRGO41t7EFII1KmAUgjL8c6bCw__Cc6hoteely2Pg.
+			QRsOLaELO2d2MCjpFZKsCg`},
+}
+
+const (
+	testJWEECDHESKeyP256 = `{"kty":"EC","crv":"P-256",
+		"x":"BHId3zoDv6pDgOUh8rKdloUZ0YumRTcaVDCppUPoYgk",
+		"y":"g3QIDhaWEksYtZ9OWjNHn9a6-i_P9o5_NrdISP0VWDU",
+		"d":"KpTnMOHEpskXvuXHFCfiRtGUHUZ9Dq5CCcZQ-19rYs4"}`
+	testJWEECDHESKeyP521 = `{"kty":"EC","crv":"P-521",
+		"x":"APhJyzW4IkVv2eb_bNTx5V_vXYNkJVaYV2KqKxkjUIk-cMVxinRyN6WACIuU7W15KM0DPX8cwzor5ODkUuDblMxg",
+		"y":"ADxHYXBqI3lQthSnjwj2bOqgwQoDlC0LOrG-rBqyvPBbGUNPQPHLQd_aDONSskKgE8LZrD36F07agqBp2NDrfC4g",
+		"d":"AN6BCYXPe3SwU1-pHXmgiRYVsDvLgT5vE04OrhTTOKBTKkrb0CfnIVRyR2ptoXTzppL854nkY5WYe8mdm4O1arNw"}`
+	testJWEECDHESPlaintext = `{"exp":1392553211,"sub":"alice","nbf":1392552611,"aud":["https:\/\/app-one.com","https:\/\/app-two.com"],"iss":"https:\/\/openid.net","jti":"586dd129-a29f-49c8-9de7-454af1155e27","iat":1392552611}`
+)
+
+func TestJWEDecryptECDHES(t *testing.T) {
+	for i, data := range testJWEECDHESVectors {
+		var jwk JWK
+		if err := json.Unmarshal([]byte(data.jwk), &jwk); err != nil {
+			t.Fatal(err)
+		}
+		key, err := jwk.Key()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		value := strings.NewReplacer("\n", "", "\t", "").Replace(data.value)
+		token := NewEncryptedToken(data.alg, data.enc, nil)
+		if err := token.Parse(value); err != nil {
+			t.Fatalf("[%d] %v", i, err)
+		}
+		if err := token.Decrypt(key); err != nil {
+			t.Fatalf("[%d] %v", i, err)
+		}
+		if got := string(token.Plaintext); got != testJWEECDHESPlaintext {
+			t.Errorf("[%d] got '%v' want '%v'", i, got, testJWEECDHESPlaintext)
+		}
+	}
+}
+
+func TestJWEECDHES(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, alg := range []uint{ECDH_ES, ECDH_ES_A128KW, ECDH_ES_A256KW} {
+			for _, enc := range []uint{A128GCM, A256CBC_HS512} {
+				token := NewEncryptedToken(alg, enc, &IanaClaims{Subject: "1234567890"})
+				token.Header["apu"] = EncodeSegment([]byte("Alice"))
+				token.Header["apv"] = EncodeSegment([]byte("Bob"))
+				if err := token.Encrypt(&key.PublicKey); err != nil {
+					t.Fatalf("[%d/%d] %v", alg, enc, err)
+				}
+
+				claims := &IanaClaims{}
+				parsed := NewEncryptedToken(alg, enc, claims)
+				if err := parsed.Parse(token.Value); err != nil {
+					t.Fatalf("[%d/%d] %v", alg, enc, err)
+				}
+				if _, ok := parsed.Header["epk"]; !ok {
+					t.Errorf("[%d/%d] Missing epk header", alg, enc)
+				}
+				if err := parsed.Decrypt(key); err != nil {
+					t.Fatalf("[%d/%d] %v", alg, enc, err)
+				}
+				if claims.Subject != "1234567890" {
+					t.Errorf("[%d/%d] got '%v' want '%v'", alg, enc, claims.Subject, "1234567890")
+				}
+			}
+		}
+	}
+}
+
+func TestJWEECDHESInvalidKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := NewEncryptedToken(ECDH_ES, A128GCM, &IanaClaims{})
+	if err := token.Encrypt(&key.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := NewEncryptedToken(ECDH_ES, A128GCM, &IanaClaims{})
+	if err := parsed.Parse(token.Value); err != nil {
+		t.Fatal(err)
+	}
+
+	// Point not on the curve
+	epk := parsed.Header["epk"].(map[string]interface{})
+	epk["y"] = epk["x"]
+	if err := parsed.Decrypt(key); err == nil {
+		t.Error("Expected error for invalid epk")
+	}
+
+	// Curve mismatch
+	other, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parsed.Parse(token.Value); err != nil {
+		t.Fatal(err)
+	}
+	if err := parsed.Decrypt(other); err == nil {
+		t.Error("Expected error for curve mismatch")
+	}
+}
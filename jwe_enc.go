@@ -0,0 +1,153 @@
+package gojwt
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+)
+
+// EncryptMethodGCM implements the AES GCM content encryption algorithms
+// A128GCM and A256GCM (RFC 7518 section 5.3).
+type EncryptMethodGCM struct {
+	KeyLen int
+}
+
+func (m EncryptMethodGCM) KeySize() int {
+	return m.KeyLen
+}
+
+func (m EncryptMethodGCM) Encrypt(cek, plaintext, aad []byte) ([]byte, []byte, []byte, error) {
+	aead, err := m.aead(cek)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	iv := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, nil, err
+	}
+
+	out := aead.Seal(nil, iv, plaintext, aad)
+	n := len(out) - aead.Overhead()
+	return iv, out[:n], out[n:], nil
+}
+
+func (m EncryptMethodGCM) Decrypt(cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	aead, err := m.aead(cek)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != aead.NonceSize() || len(tag) != aead.Overhead() {
+		return nil, errors.New("Invalid initialization vector or authentication tag length")
+	}
+
+	data := make([]byte, 0, len(ciphertext)+len(tag))
+	data = append(data, ciphertext...)
+	data = append(data, tag...)
+
+	plaintext, err := aead.Open(nil, iv, data, aad)
+	if err != nil {
+		return nil, errors.New("Failed to decrypt content")
+	}
+
+	return plaintext, nil
+}
+
+func (m EncryptMethodGCM) aead(cek []byte) (cipher.AEAD, error) {
+	if len(cek) != m.KeyLen {
+		return nil, errors.New("Invalid content encryption key length")
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// EncryptMethodCBCHMAC implements the AES CBC HMAC SHA2 content encryption
+// algorithms A128CBC-HS256 and A256CBC-HS512 (RFC 7518 section 5.2). The
+// key is the concatenation of the MAC key and the encryption key.
+type EncryptMethodCBCHMAC struct {
+	KeyLen int
+	Hash   crypto.Hash
+}
+
+func (m EncryptMethodCBCHMAC) KeySize() int {
+	return m.KeyLen
+}
+
+func (m EncryptMethodCBCHMAC) Encrypt(cek, plaintext, aad []byte) ([]byte, []byte, []byte, error) {
+	if len(cek) != m.KeyLen {
+		return nil, nil, nil, errors.New("Invalid content encryption key length")
+	}
+
+	block, err := aes.NewCipher(cek[m.KeyLen/2:])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, nil, nil, err
+	}
+
+	pad := aes.BlockSize - len(plaintext)%aes.BlockSize
+	ciphertext := make([]byte, len(plaintext), len(plaintext)+pad)
+	copy(ciphertext, plaintext)
+	for i := 0; i < pad; i++ {
+		ciphertext = append(ciphertext, byte(pad))
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+
+	return iv, ciphertext, m.tag(cek[:m.KeyLen/2], aad, iv, ciphertext), nil
+}
+
+func (m EncryptMethodCBCHMAC) Decrypt(cek, iv, ciphertext, tag, aad []byte) ([]byte, error) {
+	if len(cek) != m.KeyLen {
+		return nil, errors.New("Invalid content encryption key length")
+	}
+
+	if !hmac.Equal(tag, m.tag(cek[:m.KeyLen/2], aad, iv, ciphertext)) {
+		return nil, errors.New("Failed to decrypt content")
+	}
+
+	block, err := aes.NewCipher(cek[m.KeyLen/2:])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, errors.New("Failed to decrypt content")
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	plaintext, err = pkcs7Unpad(plaintext, aes.BlockSize)
+	if err != nil {
+		return nil, errors.New("Failed to decrypt content")
+	}
+
+	return plaintext, nil
+}
+
+// tag computes the authentication tag over AAD || IV || ciphertext || AL,
+// truncated to half of the HMAC output.
+func (m EncryptMethodCBCHMAC) tag(key, aad, iv, ciphertext []byte) []byte {
+	var al [8]byte
+	binary.BigEndian.PutUint64(al[:], uint64(len(aad))*8)
+
+	h := hmac.New(m.Hash.New, key)
+	h.Write(aad)
+	h.Write(iv)
+	h.Write(ciphertext)
+	h.Write(al[:])
+	return h.Sum(nil)[:m.KeyLen/2]
+}
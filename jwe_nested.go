@@ -0,0 +1,64 @@
+package gojwt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SignAndEncrypt builds a nested JWT (RFC 7519 section 5.2): the token is
+// signed with signKey and the compact result becomes the plaintext of the
+// encrypted token, created with NewEncryptedToken and a nil claims, which
+// is encrypted for encryptKey with the "cty" header set to "JWT". The
+// nested token is found in outer.Value.
+func SignAndEncrypt(token *Token, signKey interface{}, outer *EncryptedToken, encryptKey interface{}) error {
+	if err := token.Sign(signKey); err != nil {
+		return err
+	}
+
+	if outer.Header == nil {
+		outer.Header = make(map[string]interface{})
+	}
+	outer.Header["cty"] = "JWT"
+	outer.Payload = nil
+	outer.Plaintext = []byte(token.Value)
+
+	return outer.Encrypt(encryptKey)
+}
+
+// DecryptAndVerify is the inverse of SignAndEncrypt. The nested token is
+// parsed into outer and decrypted with decryptKey; its "cty" header must be
+// "JWT". The inner token is then parsed into token, its signature verified
+// with verifyKey and its claims validated. The signing algorithm of the
+// inner token must match the one token was created with.
+func DecryptAndVerify(tokenString string, outer *EncryptedToken, decryptKey interface{}, token *Token, verifyKey interface{}) error {
+	if err := outer.Parse(tokenString); err != nil {
+		return err
+	}
+	if cty, _ := outer.Header["cty"].(string); !strings.EqualFold(cty, "JWT") {
+		return errors.New("The encrypted token does not contain a nested JWT")
+	}
+
+	outer.Payload = nil
+	if err := outer.Decrypt(decryptKey); err != nil {
+		return err
+	}
+
+	if token.Method == nil {
+		return errors.New("Missing signing method")
+	}
+	alg, _ := token.Header["alg"].(string)
+
+	if err := Parse(token, string(outer.Plaintext), false); err != nil {
+		return err
+	}
+	if got, _ := token.Header["alg"].(string); alg != "" && got != alg {
+		return fmt.Errorf("Unexpected signing algorithm in the nested token: %q", got)
+	}
+
+	if err := token.Verify(verifyKey); err != nil {
+		return &TokenError{Text: err, Flags: ErrorIvalidSignature}
+	}
+
+	return token.Validate()
+}
@@ -0,0 +1,77 @@
+package gojwt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNestedToken(t *testing.T) {
+	signKey, err := ParseRSAPrivateKey([]byte(testVectorRSA[0].pemData), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptKey, _ := randomKey(32)
+
+	token := NewToken(RS256, &IanaClaims{Subject: "1234567890"})
+	outer := NewEncryptedToken(A256KW, A256GCM, nil)
+	if err := SignAndEncrypt(token, signKey, outer, encryptKey); err != nil {
+		t.Fatal(err)
+	}
+	if got := outer.Header["cty"]; got != "JWT" {
+		t.Errorf("got '%v' want '%v'", got, "JWT")
+	}
+
+	claims := &IanaClaims{}
+	inner := NewToken(RS256, claims)
+	err = DecryptAndVerify(outer.Value, NewEncryptedToken(A256KW, A256GCM, nil), encryptKey,
+		inner, &signKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "1234567890" {
+		t.Errorf("got '%v' want '%v'", claims.Subject, "1234567890")
+	}
+
+	// Unexpected signing algorithm
+	err = DecryptAndVerify(outer.Value, NewEncryptedToken(A256KW, A256GCM, nil), encryptKey,
+		NewToken(PS256, &IanaClaims{}), &signKey.PublicKey)
+	if err == nil {
+		t.Error("Expected error for unexpected signing algorithm")
+	}
+
+	// Wrong verification key
+	other, _ := GenerateRSAKey(2048)
+	err = DecryptAndVerify(outer.Value, NewEncryptedToken(A256KW, A256GCM, nil), encryptKey,
+		NewToken(RS256, &IanaClaims{}), &other.PublicKey)
+	if e, ok := err.(*TokenError); !ok || e.Flags&ErrorIvalidSignature == 0 {
+		t.Errorf("got '%v' want signature error", err)
+	}
+}
+
+func TestNestedTokenInvalid(t *testing.T) {
+	signKey := testHMACKey
+	encryptKey, _ := randomKey(16)
+
+	// Expired inner token
+	token := NewToken(HS256, &IanaClaims{ExpiresAt: time.Now().Add(-time.Hour).Unix()})
+	outer := NewEncryptedToken(A128KW, A128GCM, nil)
+	if err := SignAndEncrypt(token, signKey, outer, encryptKey); err != nil {
+		t.Fatal(err)
+	}
+	err := DecryptAndVerify(outer.Value, NewEncryptedToken(A128KW, A128GCM, nil), encryptKey,
+		NewToken(HS256, &IanaClaims{}), signKey)
+	if e, ok := err.(*TokenError); !ok || e.Flags&ErrorInvalidExpiration == 0 {
+		t.Errorf("got '%v' want expiration error", err)
+	}
+
+	// Encrypted claims without cty
+	plain := NewEncryptedToken(A128KW, A128GCM, &IanaClaims{})
+	if err := plain.Encrypt(encryptKey); err != nil {
+		t.Fatal(err)
+	}
+	err = DecryptAndVerify(plain.Value, NewEncryptedToken(A128KW, A128GCM, nil), encryptKey,
+		NewToken(HS256, &IanaClaims{}), signKey)
+	if err == nil {
+		t.Error("Expected error for missing cty header")
+	}
+}
@@ -0,0 +1,156 @@
+package gojwt
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Iteration counts of PBES2 key management. Tokens are encrypted with
+// PBES2Iterations unless the method sets its own count; the count found in
+// the "p2c" header of a received token must lie between PBES2MinIterations
+// and PBES2MaxIterations, so that a crafted token cannot make the key
+// derivation arbitrarily expensive. The maximum keeps a PBES2-HS512+A256KW
+// derivation around a quarter of a second on current hardware.
+const (
+	PBES2Iterations    = 100000
+	PBES2MinIterations = 1000
+	PBES2MaxIterations = 200000
+)
+
+// pbes2SaltSize is the length of the random "p2s" salt input
+const pbes2SaltSize = 16
+
+// KeyMethodPBES2 implements password based encryption (PBES2-HS256+A128KW,
+// PBES2-HS512+A256KW, RFC 7518 section 4.8). The key encryption key is
+// derived from the password with PBKDF2 and wraps a random CEK with AES Key
+// Wrap. The salt input and the iteration count are sent in the "p2s" and
+// "p2c" headers. The key is the password as []byte or string.
+type KeyMethodPBES2 struct {
+	Hash   crypto.Hash
+	KeyLen int
+
+	// Iterations overrides PBES2Iterations when encrypting
+	Iterations int
+
+	// MinIterations and MaxIterations override the accepted range of the
+	// "p2c" header when decrypting
+	MinIterations int
+	MaxIterations int
+}
+
+func (m KeyMethodPBES2) EncryptKey(key interface{}, enc EncryptMethod, header map[string]interface{}) ([]byte, []byte, error) {
+	password, err := m.password(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	iter := m.Iterations
+	if iter == 0 {
+		iter = PBES2Iterations
+	}
+	if err := m.checkIterations(iter); err != nil {
+		return nil, nil, err
+	}
+
+	salt, err := randomKey(pbes2SaltSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	header["p2s"] = EncodeSegment(salt)
+	header["p2c"] = iter
+
+	cek, err := randomKey(enc.KeySize())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedKey, err := aesKeyWrap(m.deriveKey(password, header, salt, iter), cek)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cek, encryptedKey, nil
+}
+
+func (m KeyMethodPBES2) DecryptKey(key interface{}, enc EncryptMethod, header map[string]interface{}, encryptedKey []byte) ([]byte, error) {
+	password, err := m.password(key)
+	if err != nil {
+		return nil, err
+	}
+
+	salt, err := decodeHeaderSegment(header, "p2s")
+	if err != nil {
+		return nil, err
+	}
+	if len(salt) < 8 {
+		return nil, errors.New("Missing or invalid p2s header")
+	}
+
+	iter, ok := headerInt(header, "p2c")
+	if !ok {
+		return nil, errors.New("Missing or invalid p2c header")
+	}
+	if err := m.checkIterations(iter); err != nil {
+		return nil, err
+	}
+
+	return aesKeyUnwrap(m.deriveKey(password, header, salt, iter), encryptedKey)
+}
+
+// deriveKey derives the key encryption key. The PBKDF2 salt is the UTF-8
+// "alg" value, a zero byte and the "p2s" salt input.
+func (m KeyMethodPBES2) deriveKey(password []byte, header map[string]interface{}, salt []byte, iter int) []byte {
+	alg, _ := header["alg"].(string)
+	s := make([]byte, 0, len(alg)+1+len(salt))
+	s = append(s, alg...)
+	s = append(s, 0)
+	s = append(s, salt...)
+
+	return pbkdf2Key(password, s, iter, m.KeyLen, m.Hash.New)
+}
+
+func (m KeyMethodPBES2) checkIterations(iter int) error {
+	min, max := m.MinIterations, m.MaxIterations
+	if min == 0 {
+		min = PBES2MinIterations
+	}
+	if max == 0 {
+		max = PBES2MaxIterations
+	}
+	if iter < min || iter > max {
+		return fmt.Errorf("PBES2 iteration count %d out of range [%d, %d]", iter, min, max)
+	}
+	return nil
+}
+
+func (m KeyMethodPBES2) password(key interface{}) ([]byte, error) {
+	switch k := key.(type) {
+	case []byte:
+		if len(k) != 0 {
+			return k, nil
+		}
+	case string:
+		if k != "" {
+			return []byte(k), nil
+		}
+	default:
+		return nil, fmt.Errorf("Invalid key type %T, PBES2 requires a []byte or string password", key)
+	}
+	return nil, errors.New("Empty PBES2 password")
+}
+
+// headerInt returns an integer header parameter, either set by the caller
+// or decoded from json.
+func headerInt(header map[string]interface{}, name string) (int, bool) {
+	switch v := header[name].(type) {
+	case int:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) && v >= 0 && v <= math.MaxInt32 {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
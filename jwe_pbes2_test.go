@@ -0,0 +1,105 @@
+package gojwt
+
+import (
+	"crypto"
+	"strings"
+	"testing"
+)
+
+func TestJWEPBES2(t *testing.T) {
+	password := []byte("Thus from my lips, by yours, my sin is purged.")
+
+	for _, alg := range []uint{PBES2_HS256_A128KW, PBES2_HS512_A256KW} {
+		for _, enc := range []uint{A128GCM, A128CBC_HS256, A256CBC_HS512} {
+			token := NewEncryptedToken(alg, enc, &IanaClaims{Subject: "1234567890"})
+			method := token.KeyMethod.(KeyMethodPBES2)
+			method.Iterations = 4096
+			token.KeyMethod = method
+			if err := token.Encrypt(password); err != nil {
+				t.Fatalf("[%d/%d] %v", alg, enc, err)
+			}
+
+			claims := &IanaClaims{}
+			parsed := NewEncryptedToken(alg, enc, claims)
+			if err := parsed.Parse(token.Value); err != nil {
+				t.Fatalf("[%d/%d] %v", alg, enc, err)
+			}
+			if got, _ := headerInt(parsed.Header, "p2c"); got != 4096 {
+				t.Errorf("[%d/%d] got '%v' want '%v'", alg, enc, got, 4096)
+			}
+			if err := parsed.Decrypt(string(password)); err != nil {
+				t.Fatalf("[%d/%d] %v", alg, enc, err)
+			}
+			if claims.Subject != "1234567890" {
+				t.Errorf("[%d/%d] got '%v' want '%v'", alg, enc, claims.Subject, "1234567890")
+			}
+
+			if err := parsed.Decrypt([]byte("wrong password")); err == nil {
+				t.Errorf("[%d/%d] Expected error for wrong password", alg, enc)
+			}
+		}
+	}
+}
+
+func TestJWEPBES2Iterations(t *testing.T) {
+	password := []byte("mysecret")
+
+	// Counts outside the accepted range are refused before deriving a key
+	for _, iter := range []int{PBES2MinIterations - 1, PBES2MaxIterations + 1} {
+		token := NewEncryptedToken(PBES2_HS256_A128KW, A128GCM, &IanaClaims{})
+		token.KeyMethod = KeyMethodPBES2{Hash: crypto.SHA256, KeyLen: 16, Iterations: iter}
+		if err := token.Encrypt(password); err == nil {
+			t.Errorf("[%d] Expected error for iteration count", iter)
+		}
+	}
+
+	token := NewEncryptedToken(PBES2_HS256_A128KW, A128GCM, &IanaClaims{})
+	token.KeyMethod = KeyMethodPBES2{Hash: crypto.SHA256, KeyLen: 16, Iterations: 5000}
+	if err := token.Encrypt(password); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := NewEncryptedToken(PBES2_HS256_A128KW, A128GCM, &IanaClaims{})
+	parsed.KeyMethod = KeyMethodPBES2{Hash: crypto.SHA256, KeyLen: 16, MaxIterations: 4000}
+	if err := parsed.Parse(token.Value); err != nil {
+		t.Fatal(err)
+	}
+	if err := parsed.Decrypt(password); err == nil || !strings.Contains(err.Error(), "iteration") {
+		t.Errorf("got '%v' want iteration count error", err)
+	}
+
+	// A token crafted with a huge count
+	parts := strings.Split(token.Value, ".")
+	parts[0] = EncodeSegment([]byte(`{"alg":"PBES2-HS256+A128KW","enc":"A128GCM",` +
+		`"p2s":"` + parsed.Header["p2s"].(string) + `","p2c":1000000000}`))
+	parsed = NewEncryptedToken(PBES2_HS256_A128KW, A128GCM, &IanaClaims{})
+	if err := parsed.Parse(strings.Join(parts, ".")); err != nil {
+		t.Fatal(err)
+	}
+	if err := parsed.Decrypt(password); err == nil {
+		t.Error("Expected error for huge iteration count")
+	}
+
+	// Missing p2c
+	delete(parsed.Header, "p2c")
+	if err := parsed.Decrypt(password); err == nil {
+		t.Error("Expected error for missing p2c header")
+	}
+}
+
+func TestJWEPBES2Lookup(t *testing.T) {
+	token := NewEncryptedToken(PBES2_HS256_A128KW, A128GCM, &IanaClaims{})
+	token.KeyMethod = KeyMethodPBES2{Hash: crypto.SHA256, KeyLen: 16, Iterations: 4096}
+	if err := token.Encrypt([]byte("mysecret")); err != nil {
+		t.Fatal(err)
+	}
+
+	// PBES2 is not taken from the header of a token without expectations
+	parsed := &EncryptedToken{Payload: &IanaClaims{}}
+	if err := parsed.Parse(token.Value); err == nil {
+		t.Error("Expected error for PBES2 selected from the header")
+	}
+	if parsed.KeyMethod != nil {
+		t.Errorf("got '%v' want '%v'", parsed.KeyMethod, nil)
+	}
+}
@@ -0,0 +1,60 @@
+package gojwt
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+)
+
+// KeyMethodRSAOAEP implements RSAES OAEP encryption of a random CEK with
+// the recipient RSA public key (RSA-OAEP, RSA-OAEP-256, RFC 7518 section
+// 4.3). RSA-OAEP uses SHA-1 and RSA-OAEP-256 uses SHA-256 for both the
+// hash and MGF1.
+type KeyMethodRSAOAEP struct {
+	Hash crypto.Hash
+
+	// AllowWeakKeys disables the minimum key size check, for
+	// interoperability with legacy keys smaller than MinRSAKeyBits.
+	AllowWeakKeys bool
+}
+
+func (m KeyMethodRSAOAEP) EncryptKey(key interface{}, enc EncryptMethod, header map[string]interface{}) ([]byte, []byte, error) {
+	if k, ok := key.(*rsa.PrivateKey); ok {
+		key = &k.PublicKey
+	}
+	pub, err := checkRSAPublicKey(key, m.AllowWeakKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cek, err := randomKey(enc.KeySize())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(m.Hash.New(), rand.Reader, pub, cek, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cek, encryptedKey, nil
+}
+
+func (m KeyMethodRSAOAEP) DecryptKey(key interface{}, enc EncryptMethod, header map[string]interface{}, encryptedKey []byte) ([]byte, error) {
+	pkey, err := checkRSAPrivateKey(key, m.AllowWeakKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	cek, err := rsa.DecryptOAEP(m.Hash.New(), rand.Reader, pkey, encryptedKey, nil)
+	if err != nil {
+		return nil, errors.New("Failed to decrypt key")
+	}
+
+	if len(cek) != enc.KeySize() {
+		return nil, errors.New("Invalid content encryption key length")
+	}
+
+	return cek, nil
+}
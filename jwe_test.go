@@ -0,0 +1,150 @@
+package gojwt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJWEDecryptA128KW(t *testing.T) {
+	// RFC 7516 appendix A.3
+	tokenString := "eyJhbGciOiJBMTI4S1ciLCJlbmMiOiJBMTI4Q0JDLUhTMjU2In0." +
+		"6KB707dM9YTIgHtLvtgWQ8mKwboJW3of9locizkDTHzBC2IlrT1oOQ." +
+		"AxY8DCtDaGlsbGljb3RoZQ." +
+		"KDlTtXchhZTGufMYmOYGS4HffxPSUrfmqCHXaI9wOGY." +
+		"U0m_YmjN04DJvceFICbCVQ"
+	key, _ := DecodeSegment("GawgguFyGrWKav7AX4VKUg")
+
+	token := NewEncryptedToken(A128KW, A128CBC_HS256, nil)
+	if err := token.Parse(tokenString); err != nil {
+		t.Fatal(err)
+	}
+	if err := token.Decrypt(key); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Live long and prosper."
+	if got := string(token.Plaintext); got != want {
+		t.Errorf("got '%v' want '%v'", got, want)
+	}
+}
+
+func TestJWE(t *testing.T) {
+	for _, alg := range []uint{DIR, A128KW, A256KW} {
+		for _, enc := range []uint{A128GCM, A256GCM, A128CBC_HS256, A256CBC_HS512} {
+			size := EncryptMethodTable[enc].Method.KeySize()
+			if alg != DIR {
+				size = KeyMethodTable[alg].Method.(KeyMethodAESKW).KeyLen
+			}
+			key, err := randomKey(size)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token := NewEncryptedToken(alg, enc, &IanaClaims{Subject: "1234567890"})
+			if err := token.Encrypt(key); err != nil {
+				t.Fatalf("[%d/%d] %v", alg, enc, err)
+			}
+			if got := strings.Count(token.Value, "."); got != 4 {
+				t.Errorf("[%d/%d] got '%v' want '%v'", alg, enc, got, 4)
+			}
+
+			claims := &IanaClaims{}
+			parsed := NewEncryptedToken(alg, enc, claims)
+			if err := parsed.Parse(token.Value); err != nil {
+				t.Fatalf("[%d/%d] %v", alg, enc, err)
+			}
+			if err := parsed.Decrypt(key); err != nil {
+				t.Fatalf("[%d/%d] %v", alg, enc, err)
+			}
+			if err := parsed.Validate(); err != nil {
+				t.Errorf("[%d/%d] %v", alg, enc, err)
+			}
+			if claims.Subject != "1234567890" {
+				t.Errorf("[%d/%d] got '%v' want '%v'", alg, enc, claims.Subject, "1234567890")
+			}
+
+			// Any change to the protected header must be detected
+			parts := strings.Split(token.Value, ".")
+			parts[0] = EncodeSegment([]byte(`{"alg":"` + KeyMethodTable[alg].Alg +
+				`","enc":"` + EncryptMethodTable[enc].Enc + `","kid":"1"}`))
+			tampered := NewEncryptedToken(alg, enc, &IanaClaims{})
+			if err := tampered.Parse(strings.Join(parts, ".")); err != nil {
+				t.Fatalf("[%d/%d] %v", alg, enc, err)
+			}
+			if err := tampered.Decrypt(key); err == nil {
+				t.Errorf("[%d/%d] Expected error for tampered header", alg, enc)
+			}
+		}
+	}
+}
+
+func TestJWEWrongKey(t *testing.T) {
+	key, _ := randomKey(16)
+	token := NewEncryptedToken(A128KW, A128GCM, &IanaClaims{})
+	if err := token.Encrypt(key); err != nil {
+		t.Fatal(err)
+	}
+
+	other, _ := randomKey(16)
+	parsed := NewEncryptedToken(A128KW, A128GCM, &IanaClaims{})
+	if err := parsed.Parse(token.Value); err != nil {
+		t.Fatal(err)
+	}
+	if err := parsed.Decrypt(other); err == nil {
+		t.Error("Expected error for wrong key")
+	}
+
+	// The header must match the expected algorithms
+	if err := NewEncryptedToken(A256KW, A128GCM, nil).Parse(token.Value); err == nil {
+		t.Error("Expected error for unexpected key management algorithm")
+	}
+	if err := NewEncryptedToken(A128KW, A256GCM, nil).Parse(token.Value); err == nil {
+		t.Error("Expected error for unexpected content encryption algorithm")
+	}
+
+	// Without expectations the methods are taken from the header
+	parsed = &EncryptedToken{Payload: &IanaClaims{}}
+	if err := parsed.Parse(token.Value); err != nil {
+		t.Fatal(err)
+	}
+	if err := parsed.Decrypt(key); err != nil {
+		t.Error(err)
+	}
+
+	if err := parsed.Parse("a.b.c"); err == nil {
+		t.Error("Expected error for invalid segment count")
+	}
+}
+
+func TestAESKeyWrap(t *testing.T) {
+	// RFC 3394 section 4.1
+	kek := []byte{
+		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07,
+		0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+	}
+	key := []byte{
+		0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77,
+		0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+	}
+	want := []byte{
+		0x1f, 0xa6, 0x8b, 0x0a, 0x81, 0x12, 0xb4, 0x47,
+		0xae, 0xf3, 0x4b, 0xd8, 0xfb, 0x5a, 0x7b, 0x82,
+		0x9d, 0x3e, 0x86, 0x23, 0x71, 0xd2, 0xcf, 0xe5,
+	}
+
+	got, err := aesKeyWrap(kek, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got '%x' want '%x'", got, want)
+	}
+
+	got, err = aesKeyUnwrap(kek, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(key) {
+		t.Errorf("got '%x' want '%x'", got, key)
+	}
+}
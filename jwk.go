@@ -0,0 +1,237 @@
+package gojwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// JWK is the JSON Web Key representation of a key (RFC 7517). Members are
+// base64url encoded as defined in RFC 7518 section 6 and RFC 8037.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+
+	// EC and OKP keys
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+
+	// RSA keys
+	N  string `json:"n,omitempty"`
+	E  string `json:"e,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	Dp string `json:"dp,omitempty"`
+	Dq string `json:"dq,omitempty"`
+	Qi string `json:"qi,omitempty"`
+
+	// Private exponent (RSA) or private key (EC, OKP)
+	D string `json:"d,omitempty"`
+
+	// Symmetric keys
+	K string `json:"k,omitempty"`
+}
+
+// NewJWK returns the JWK representation of the key. Supported keys are
+// RSA, EC (P-256, P-384, P-521), Ed25519 and symmetric ([]byte) keys, both
+// private and public.
+func NewJWK(key interface{}) (*JWK, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return &JWK{
+			Kty: "RSA",
+			N:   EncodeSegment(k.N.Bytes()),
+			E:   EncodeSegment(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *rsa.PrivateKey:
+		if len(k.Primes) != 2 {
+			return nil, errors.New("Multi-prime RSA keys are not supported")
+		}
+		k.Precompute()
+		jwk, _ := NewJWK(&k.PublicKey)
+		jwk.D = EncodeSegment(k.D.Bytes())
+		jwk.P = EncodeSegment(k.Primes[0].Bytes())
+		jwk.Q = EncodeSegment(k.Primes[1].Bytes())
+		jwk.Dp = EncodeSegment(k.Precomputed.Dp.Bytes())
+		jwk.Dq = EncodeSegment(k.Precomputed.Dq.Bytes())
+		jwk.Qi = EncodeSegment(k.Precomputed.Qinv.Bytes())
+		return jwk, nil
+	case *ecdsa.PublicKey:
+		crv, size, err := curveParams(k)
+		if err != nil {
+			return nil, err
+		}
+		return &JWK{
+			Kty: "EC",
+			Crv: crv,
+			X:   EncodeSegment(padBytes(k.X.Bytes(), size)),
+			Y:   EncodeSegment(padBytes(k.Y.Bytes(), size)),
+		}, nil
+	case *ecdsa.PrivateKey:
+		jwk, err := NewJWK(&k.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		_, size, _ := curveParams(&k.PublicKey)
+		jwk.D = EncodeSegment(padBytes(k.D.Bytes(), size))
+		return jwk, nil
+	case ed25519.PublicKey:
+		return &JWK{Kty: "OKP", Crv: "Ed25519", X: EncodeSegment(k)}, nil
+	case ed25519.PrivateKey:
+		jwk, _ := NewJWK(k.Public())
+		jwk.D = EncodeSegment(k.Seed())
+		return jwk, nil
+	case []byte:
+		return &JWK{Kty: "oct", K: EncodeSegment(k)}, nil
+	}
+
+	return nil, fmt.Errorf("Unsupported key type for JWK: %T", key)
+}
+
+// Public returns a copy of the JWK without the private key members.
+// Symmetric keys have no public half and yield nil.
+func (j *JWK) Public() *JWK {
+	if j.Kty == "oct" {
+		return nil
+	}
+
+	return &JWK{
+		Kty: j.Kty,
+		Kid: j.Kid,
+		Use: j.Use,
+		Alg: j.Alg,
+		Crv: j.Crv,
+		X:   j.X,
+		Y:   j.Y,
+		N:   j.N,
+		E:   j.E,
+	}
+}
+
+// Key returns the key described by the JWK: *rsa.PublicKey,
+// *rsa.PrivateKey, *ecdsa.PublicKey, *ecdsa.PrivateKey, ed25519.PublicKey,
+// ed25519.PrivateKey or []byte, depending on the key type and on whether
+// the private members are present.
+func (j *JWK) Key() (interface{}, error) {
+	switch j.Kty {
+	case "RSA":
+		return j.rsaKey()
+	case "EC":
+		return j.ecKey()
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, errors.New("Unsupported JWK curve: " + j.Crv)
+		}
+		x, err := DecodeSegment(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("Invalid JWK Ed25519 public key")
+		}
+		if j.D == "" {
+			return ed25519.PublicKey(x), nil
+		}
+		d, err := DecodeSegment(j.D)
+		if err != nil || len(d) != ed25519.SeedSize {
+			return nil, errors.New("Invalid JWK Ed25519 private key")
+		}
+		return ed25519.NewKeyFromSeed(d), nil
+	case "oct":
+		return DecodeSegment(j.K)
+	}
+
+	return nil, errors.New("Unsupported JWK key type: " + j.Kty)
+}
+
+func (j *JWK) rsaKey() (interface{}, error) {
+	n, err := decodeBigInt(j.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(j.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, errors.New("Invalid JWK RSA exponent")
+	}
+
+	pub := &rsa.PublicKey{N: n, E: int(e.Int64())}
+	if j.D == "" {
+		return pub, nil
+	}
+
+	key := &rsa.PrivateKey{PublicKey: *pub}
+	if key.D, err = decodeBigInt(j.D); err != nil {
+		return nil, err
+	}
+	p, err := decodeBigInt(j.P)
+	if err != nil {
+		return nil, err
+	}
+	q, err := decodeBigInt(j.Q)
+	if err != nil {
+		return nil, err
+	}
+	key.Primes = []*big.Int{p, q}
+
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	key.Precompute()
+	return key, nil
+}
+
+func (j *JWK) ecKey() (interface{}, error) {
+	var curve elliptic.Curve
+	switch j.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, errors.New("Unsupported JWK curve: " + j.Crv)
+	}
+
+	x, err := decodeBigInt(j.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(j.Y)
+	if err != nil {
+		return nil, err
+	}
+	pub := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	if _, err := pub.ECDH(); err != nil {
+		return nil, errors.New("Invalid JWK EC public key")
+	}
+	if j.D == "" {
+		return pub, nil
+	}
+
+	d, err := decodeBigInt(j.D)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PrivateKey{PublicKey: *pub, D: d}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("Missing JWK member")
+	}
+
+	b, err := DecodeSegment(s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid JWK member: %v", err)
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
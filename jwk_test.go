@@ -0,0 +1,61 @@
+package gojwt
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+)
+
+func TestJWKEd25519(t *testing.T) {
+	// RFC 8037 appendix A.1
+	d, err := DecodeSegment("nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwk, err := NewJWK(ed25519.NewKeyFromSeed(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",` +
+		`"d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A"}`
+	if got, _ := json.Marshal(jwk); string(got) != want {
+		t.Errorf("got '%s' want '%s'", got, want)
+	}
+
+	want = `{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+	if got, _ := json.Marshal(jwk.Public()); string(got) != want {
+		t.Errorf("got '%s' want '%s'", got, want)
+	}
+}
+
+func TestJWKRSA(t *testing.T) {
+	key, err := ParseRSAPrivateKey([]byte(testVectorRSA[0].pemData), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwk, err := NewJWK(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jwk.D == "" || jwk.P == "" || jwk.Q == "" || jwk.Dp == "" || jwk.Dq == "" || jwk.Qi == "" {
+		t.Errorf("Missing private members: %+v", jwk)
+	}
+
+	pub, err := NewJWK(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *pub != *jwk.Public() {
+		t.Errorf("got '%+v' want '%+v'", jwk.Public(), pub)
+	}
+	if pub.E != "AQAB" {
+		t.Errorf("got '%v' want '%v'", pub.E, "AQAB")
+	}
+
+	if (&JWK{Kty: "oct", K: "c2VjcmV0"}).Public() != nil {
+		t.Error("Symmetric keys have no public half")
+	}
+}
@@ -0,0 +1,243 @@
+package gojwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeyFunc returns the key used to verify the signature of the token,
+// typically selected from its "alg" and "kid" headers.
+type KeyFunc func(*Token) (interface{}, error)
+
+// JSONToken is a JWS in JSON serialization (RFC 7515 section 7.2). A single
+// payload carries one or more signatures, each represented by a Token with
+// its protected Header and its Unprotected header. With one signature the
+// token can be encoded in the flattened syntax.
+type JSONToken struct {
+	Payload    Claims
+	Signatures []*Token
+
+	// payload is the encoded payload shared by all the signatures
+	payload string
+}
+
+type jsonSignature struct {
+	Protected string                 `json:"protected,omitempty"`
+	Header    map[string]interface{} `json:"header,omitempty"`
+	Signature string                 `json:"signature"`
+}
+
+type jsonGeneral struct {
+	Payload    string          `json:"payload"`
+	Signatures []jsonSignature `json:"signatures"`
+}
+
+type jsonFlattened struct {
+	Payload string `json:"payload"`
+	jsonSignature
+}
+
+func NewJSONToken(claims Claims) *JSONToken {
+	return &JSONToken{Payload: claims}
+}
+
+// AddSignature signs the payload with the method and key of a token made
+// by NewToken and appends it to the signatures. Header parameters that
+// must not be integrity protected can be set in its Unprotected header.
+func (t *JSONToken) AddSignature(token *Token, key interface{}) error {
+	for name := range token.Unprotected {
+		if _, ok := token.Header[name]; ok {
+			return fmt.Errorf("Header parameter %q is both protected and unprotected", name)
+		}
+	}
+
+	token.Payload = t.Payload
+	if err := token.Sign(key); err != nil {
+		return err
+	}
+
+	payload := token.HeaderPayload[strings.IndexByte(token.HeaderPayload, '.')+1:]
+	if t.payload != "" && t.payload != payload {
+		return errors.New("The payload changed between signatures")
+	}
+	t.payload = payload
+
+	t.Signatures = append(t.Signatures, token)
+	return nil
+}
+
+// EncodeGeneral returns the general JWS JSON serialization.
+func (t *JSONToken) EncodeGeneral() ([]byte, error) {
+	if len(t.Signatures) == 0 {
+		return nil, errors.New("The token has no signatures")
+	}
+
+	v := jsonGeneral{Payload: t.payload}
+	for _, sig := range t.Signatures {
+		v.Signatures = append(v.Signatures, newJSONSignature(sig))
+	}
+
+	return json.Marshal(v)
+}
+
+// EncodeFlattened returns the flattened JWS JSON serialization, which
+// carries a single signature.
+func (t *JSONToken) EncodeFlattened() ([]byte, error) {
+	if len(t.Signatures) != 1 {
+		return nil, errors.New("The flattened syntax requires exactly one signature")
+	}
+
+	return json.Marshal(jsonFlattened{
+		Payload:       t.payload,
+		jsonSignature: newJSONSignature(t.Signatures[0]),
+	})
+}
+
+func newJSONSignature(token *Token) jsonSignature {
+	return jsonSignature{
+		Protected: token.HeaderPayload[:strings.IndexByte(token.HeaderPayload, '.')],
+		Header:    token.Unprotected,
+		Signature: token.Signature,
+	}
+}
+
+// Parse decodes a general or flattened JWS JSON serialization and the
+// payload into Payload. The "alg" header of every signature must be
+// protected. Signatures are verified separately with VerifyAny or
+// VerifyAll; those of unsupported algorithms have a nil Method.
+func (t *JSONToken) Parse(data []byte) error {
+	var v struct {
+		jsonGeneral
+		jsonSignature
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("Unable to unmarshal JWS json data: %v", err)
+	}
+
+	sigs := v.Signatures
+	if sigs == nil {
+		sigs = []jsonSignature{v.jsonSignature}
+	} else if v.Protected != "" || v.Header != nil || v.Signature != "" {
+		return errors.New("Mixed general and flattened JWS syntax")
+	}
+	if len(sigs) == 0 {
+		return errors.New("The token has no signatures")
+	}
+
+	// The headers are checked before the payload is decoded, since an
+	// unencoded payload must not be read as base64url
+	tokens := make([]*Token, len(sigs))
+	for i, sig := range sigs {
+		var err error
+		if tokens[i], err = parseJSONSignature(sig, v.Payload, t.Payload); err != nil {
+			return fmt.Errorf("Signature %d: %v", i, err)
+		}
+	}
+
+	seg, err := DecodeSegment(v.Payload)
+	if err != nil {
+		return fmt.Errorf("Invalid payload segment: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewBuffer(seg))
+	dec.UseNumber()
+	if err := dec.Decode(&t.Payload); err != nil {
+		return fmt.Errorf("Unable to decode payload data: %v", err)
+	}
+
+	t.payload = v.Payload
+	t.Signatures = tokens
+	return nil
+}
+
+func parseJSONSignature(sig jsonSignature, payload string, claims Claims) (*Token, error) {
+	seg, err := DecodeSegment(sig.Protected)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid protected header: %v", err)
+	}
+	var header map[string]interface{}
+	if err := json.Unmarshal(seg, &header); err != nil {
+		return nil, fmt.Errorf("Unable to unmarshal header json data: %v", err)
+	}
+	if err := checkCritical(header, nil); err != nil {
+		return nil, err
+	}
+	if _, ok := sig.Header["crit"]; ok {
+		return nil, errors.New("The crit header must be protected")
+	}
+	if encoded, err := payloadEncoded(header); err != nil {
+		return nil, err
+	} else if !encoded {
+		return nil, errors.New("The token has an unencoded payload")
+	}
+
+	for name := range sig.Header {
+		if _, ok := header[name]; ok {
+			return nil, fmt.Errorf("Header parameter %q is both protected and unprotected", name)
+		}
+	}
+
+	// Signatures of unsupported algorithms are kept without a method and
+	// fail verification, so that the supported ones can still be verified
+	alg, ok := header["alg"].(string)
+	if !ok {
+		return nil, errors.New("Missing alg in the protected header")
+	}
+
+	return &Token{
+		Value:         sig.Protected + "." + payload + "." + sig.Signature,
+		Method:        lookupSignMethod(alg),
+		Header:        header,
+		Unprotected:   sig.Header,
+		Payload:       claims,
+		Signature:     sig.Signature,
+		HeaderPayload: sig.Protected + "." + payload,
+	}, nil
+}
+
+// VerifyAny succeeds when at least one signature is verified with the key
+// returned by keyFunc. Signatures for which keyFunc fails are skipped.
+func (t *JSONToken) VerifyAny(keyFunc KeyFunc) error {
+	err := errors.New("The token has no signatures")
+	for _, sig := range t.Signatures {
+		if err = verifyWith(sig, keyFunc); err == nil {
+			return nil
+		}
+	}
+	return &TokenError{Text: err, Flags: ErrorIvalidSignature}
+}
+
+// VerifyAll succeeds when every signature is verified with the key
+// returned by keyFunc.
+func (t *JSONToken) VerifyAll(keyFunc KeyFunc) error {
+	if len(t.Signatures) == 0 {
+		return &TokenError{Text: errors.New("The token has no signatures"), Flags: ErrorIvalidSignature}
+	}
+	for i, sig := range t.Signatures {
+		if err := verifyWith(sig, keyFunc); err != nil {
+			return &TokenError{Text: fmt.Errorf("Signature %d: %v", i, err), Flags: ErrorIvalidSignature}
+		}
+	}
+	return nil
+}
+
+func (t *JSONToken) Validate() error {
+	if t.Payload == nil {
+		return errors.New("The token has no payload")
+	}
+	return t.Payload.Valid()
+}
+
+func verifyWith(token *Token, keyFunc KeyFunc) error {
+	if token.Method == nil {
+		return fmt.Errorf("Unsupported signing algorithm %q", token.Header["alg"])
+	}
+
+	key, err := keyFunc(token)
+	if err != nil {
+		return err
+	}
+	return token.Verify(key)
+}
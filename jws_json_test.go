@@ -0,0 +1,193 @@
+package gojwt
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testJSONKeys(t *testing.T) (*rsa.PrivateKey, []byte, KeyFunc) {
+	rsaKey, err := ParseRSAPrivateKey([]byte(testVectorRSA[0].pemData), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := testHMACKey
+
+	keyFunc := func(token *Token) (interface{}, error) {
+		switch token.Header["kid"] {
+		case "rsa":
+			return &rsaKey.PublicKey, nil
+		case "hmac":
+			return secret, nil
+		}
+		return nil, errors.New("Unknown kid")
+	}
+
+	return rsaKey, secret, keyFunc
+}
+
+func TestJSONTokenGeneral(t *testing.T) {
+	rsaKey, secret, keyFunc := testJSONKeys(t)
+
+	token := NewJSONToken(&IanaClaims{Subject: "1234567890"})
+	rs := NewToken(RS256, nil)
+	rs.Header["kid"] = "rsa"
+	rs.Unprotected = map[string]interface{}{"note": "migration"}
+	if err := token.AddSignature(rs, rsaKey); err != nil {
+		t.Fatal(err)
+	}
+	hs := NewToken(HS256, nil)
+	hs.Header["kid"] = "hmac"
+	if err := token.AddSignature(hs, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := token.EncodeGeneral()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := token.EncodeFlattened(); err == nil {
+		t.Error("Expected error for flattened syntax with two signatures")
+	}
+
+	claims := &IanaClaims{}
+	parsed := NewJSONToken(claims)
+	if err := parsed.Parse(data); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "1234567890" {
+		t.Errorf("got '%v' want '%v'", claims.Subject, "1234567890")
+	}
+	if len(parsed.Signatures) != 2 {
+		t.Fatalf("got '%v' want '%v'", len(parsed.Signatures), 2)
+	}
+	if got := parsed.Signatures[0].Unprotected["note"]; got != "migration" {
+		t.Errorf("got '%v' want '%v'", got, "migration")
+	}
+	if err := parsed.VerifyAll(keyFunc); err != nil {
+		t.Error(err)
+	}
+
+	// Each signature is a compact token
+	compact := NewToken(HS256, &IanaClaims{})
+	if err := compact.Parse(parsed.Signatures[1].Value, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := compact.Verify(secret); err != nil {
+		t.Error(err)
+	}
+
+	// Only the RSA key is known: any passes, all fails
+	rsaOnly := func(token *Token) (interface{}, error) {
+		if token.Header["kid"] != "rsa" {
+			return nil, errors.New("Unknown kid")
+		}
+		return keyFunc(token)
+	}
+	if err := parsed.VerifyAny(rsaOnly); err != nil {
+		t.Error(err)
+	}
+	if err := parsed.VerifyAll(rsaOnly); err == nil {
+		t.Error("Expected error for unverified signature")
+	}
+}
+
+func TestJSONTokenFlattened(t *testing.T) {
+	_, secret, keyFunc := testJSONKeys(t)
+
+	token := NewJSONToken(&IanaClaims{Subject: "1234567890"})
+	hs := NewToken(HS256, nil)
+	hs.Header["kid"] = "hmac"
+	if err := token.AddSignature(hs, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := token.EncodeFlattened()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "signatures") {
+		t.Errorf("got '%s' want flattened syntax", data)
+	}
+
+	parsed := NewJSONToken(&IanaClaims{})
+	if err := parsed.Parse(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := parsed.VerifyAll(keyFunc); err != nil {
+		t.Error(err)
+	}
+
+	// Tampered payload
+	var v map[string]interface{}
+	json.Unmarshal(data, &v)
+	v["payload"] = EncodeSegment([]byte(`{"sub":"admin"}`))
+	data, _ = json.Marshal(v)
+	if err := parsed.Parse(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := parsed.VerifyAny(keyFunc); err == nil {
+		t.Error("Expected error for tampered payload")
+	}
+}
+
+func TestJSONTokenInvalid(t *testing.T) {
+	_, _, keyFunc := testJSONKeys(t)
+	payload := EncodeSegment([]byte(`{}`))
+	protected := EncodeSegment([]byte(`{"alg":"HS256","kid":"hmac"}`))
+
+	for i, data := range []string{
+		// alg only in the unprotected header
+		`{"payload":"` + payload + `","protected":"` + EncodeSegment([]byte(`{}`)) +
+			`","header":{"alg":"HS256"},"signature":"AA"}`,
+		// parameter both protected and unprotected
+		`{"payload":"` + payload + `","protected":"` + protected +
+			`","header":{"kid":"rsa"},"signature":"AA"}`,
+		// mixed syntax
+		`{"payload":"` + payload + `","signature":"AA","signatures":[{"protected":"` +
+			protected + `","signature":"AA"}]}`,
+		`{"payload":"` + payload + `","signatures":[]}`,
+	} {
+		if err := NewJSONToken(&IanaClaims{}).Parse([]byte(data)); err == nil {
+			t.Errorf("[%d] Expected error", i)
+		}
+	}
+
+	// Unsupported algorithms are kept but never verified
+	data := `{"payload":"` + payload + `","protected":"` +
+		EncodeSegment([]byte(`{"alg":"ES256","kid":"hmac"}`)) + `","signature":"AA"}`
+	token := NewJSONToken(&IanaClaims{})
+	if err := token.Parse([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := token.VerifyAny(keyFunc); err == nil {
+		t.Error("Expected error for unsupported algorithm")
+	}
+}
+
+func TestJSONTokenUnencodedPayload(t *testing.T) {
+	_, secret, keyFunc := testJSONKeys(t)
+
+	// The raw payload happens to be valid base64url json
+	token := NewToken(HS256, nil)
+	token.Header["kid"] = "hmac"
+	if err := token.SignUnencoded([]byte("eyJzdWIiOiJhZG1pbiJ9"), secret); err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token.Value, ".")
+	data := `{"payload":"` + parts[1] + `","protected":"` + parts[0] + `","signature":"` + parts[2] + `"}`
+
+	claims := &IanaClaims{}
+	parsed := NewJSONToken(claims)
+	if err := parsed.Parse([]byte(data)); err == nil {
+		t.Error("Expected error for unencoded payload")
+		if err := parsed.VerifyAny(keyFunc); err == nil {
+			t.Error("Expected error for unencoded payload signature")
+		}
+	}
+	if claims.Subject != "" {
+		t.Errorf("got '%v' want '%v'", claims.Subject, "")
+	}
+}
@@ -0,0 +1,60 @@
+package gojwt
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"fmt"
+)
+
+// Key strength policy applied by the signing methods. HMAC secrets may not
+// be shorter than the hash output (RFC 7518 section 3.2) and RSA keys may
+// not be smaller than MinRSAKeyBits (RFC 7518 sections 3.3 and 3.5). The
+// checks can be disabled per method with AllowWeakKeys for interoperability
+// with legacy issuers.
+
+func checkHMACKey(hash crypto.Hash, key interface{}, allowWeak bool) ([]byte, error) {
+	secret, ok := key.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("Invalid key type %T, HMAC requires []byte", key)
+	}
+
+	if !allowWeak && len(secret) < hash.Size() {
+		return nil, fmt.Errorf("HMAC key too short, at least %d bytes required", hash.Size())
+	}
+
+	return secret, nil
+}
+
+func checkRSAPrivateKey(key interface{}, allowWeak bool) (*rsa.PrivateKey, error) {
+	pkey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("Invalid key type %T, RSA signing requires *rsa.PrivateKey", key)
+	}
+
+	if err := checkRSAKeySize(&pkey.PublicKey, allowWeak); err != nil {
+		return nil, err
+	}
+
+	return pkey, nil
+}
+
+func checkRSAPublicKey(key interface{}, allowWeak bool) (*rsa.PublicKey, error) {
+	pkey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("Invalid key type %T, RSA verification requires *rsa.PublicKey", key)
+	}
+
+	if err := checkRSAKeySize(pkey, allowWeak); err != nil {
+		return nil, err
+	}
+
+	return pkey, nil
+}
+
+func checkRSAKeySize(key *rsa.PublicKey, allowWeak bool) error {
+	if !allowWeak && key.N.BitLen() < MinRSAKeyBits {
+		return fmt.Errorf("RSA key too small, at least %d bits required", MinRSAKeyBits)
+	}
+
+	return nil
+}
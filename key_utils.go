@@ -0,0 +1,108 @@
+package gojwt
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+)
+
+// ParsePrivateKey parses a PEM encoded private key of any supported type.
+// PKCS#1 ("RSA PRIVATE KEY"), SEC1 ("EC PRIVATE KEY") and PKCS#8
+// ("PRIVATE KEY") blocks are recognised, as well as encrypted PKCS#8
+// ("ENCRYPTED PRIVATE KEY") using PBES2 and legacy encrypted PEM blocks.
+// The password is only used when the block is encrypted.
+//
+// Legacy PEM encryption (RFC 1423, "Proc-Type: 4,ENCRYPTED") is deprecated
+// and insecure: its key derivation is weak and its unauthenticated CBC
+// padding can be used as an oracle. It is only accepted for compatibility
+// with old files; use encrypted PKCS#8 instead.
+// The returned key is one of *rsa.PrivateKey, *ecdsa.PrivateKey or
+// ed25519.PrivateKey.
+func ParsePrivateKey(key []byte, password []byte) (crypto.PrivateKey, error) {
+
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, errors.New("Failed to parse PEM block")
+	}
+
+	der := block.Bytes
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		if len(password) == 0 {
+			return nil, errors.New("Password required to decrypt private key")
+		}
+		der, err := decryptPKCS8(block.Bytes, password)
+		if err != nil {
+			return nil, err
+		}
+		return x509.ParsePKCS8PrivateKey(der)
+	}
+
+	if x509.IsEncryptedPEMBlock(block) {
+		if len(password) == 0 {
+			return nil, errors.New("Password required to decrypt private key")
+		}
+		var err error
+		der, err = x509.DecryptPEMBlock(block, password)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(der)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(der)
+	}
+
+	return nil, errors.New("Unsupported private key type: " + block.Type)
+}
+
+// ParsePublicKey parses a PEM encoded public key of any supported type.
+// SubjectPublicKeyInfo ("PUBLIC KEY"), PKCS#1 ("RSA PUBLIC KEY") and X.509
+// certificate ("CERTIFICATE") blocks are recognised. The returned key is
+// one of *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+func ParsePublicKey(key []byte) (crypto.PublicKey, error) {
+
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, errors.New("Failed to parse PEM block")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+
+	return nil, errors.New("Unsupported public key type: " + block.Type)
+}
+
+func ParsePrivateKeyFromFile(filename string, password []byte) (crypto.PrivateKey, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePrivateKey(buf, password)
+}
+
+func ParsePublicKeyFromFile(filename string) (crypto.PublicKey, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePublicKey(buf)
+}
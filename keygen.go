@@ -0,0 +1,137 @@
+package gojwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// MinRSAKeyBits is the minimum RSA modulus size in bits, as required by
+// RFC 7518 sections 3.3 and 3.5.
+const MinRSAKeyBits = 2048
+
+// GenerateKey generates a new signing key for the algorithm identified by
+// id (HS256, RS256, ...). HMAC algorithms get a random secret as long as
+// the hash output (RFC 7518 section 3.2) and RSA algorithms get a
+// MinRSAKeyBits keypair. The returned key is a []byte or *rsa.PrivateKey
+// respectively. Keys for the ES* and EdDSA algorithms, which have no
+// signing method in SignMethodTable, come from GenerateECKey and
+// GenerateEd25519Key.
+func GenerateKey(id uint) (interface{}, error) {
+	if id == 0 || id >= uint(len(SignMethodTable)) {
+		return nil, errors.New("Unsupported signing algorithm")
+	}
+
+	switch m := SignMethodTable[id].Method.(type) {
+	case SignMethodHMAC:
+		return GenerateHMACKey(m.Hash, m.Hash.Size())
+	case SignMethodRSA, SignMethodRSAPSS:
+		return GenerateRSAKey(MinRSAKeyBits)
+	}
+
+	return nil, errors.New("Unsupported signing algorithm")
+}
+
+// GenerateHMACKey generates a random secret of size bytes for use with the
+// given hash. The size may not be shorter than the hash output.
+func GenerateHMACKey(hash crypto.Hash, size int) ([]byte, error) {
+	if size < hash.Size() {
+		return nil, fmt.Errorf("HMAC key must be at least %d bytes", hash.Size())
+	}
+
+	key := make([]byte, size)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// GenerateRSAKey generates an RSA keypair of the given size, which may not
+// be smaller than MinRSAKeyBits.
+func GenerateRSAKey(bits int) (*rsa.PrivateKey, error) {
+	if bits < MinRSAKeyBits {
+		return nil, fmt.Errorf("RSA key must be at least %d bits", MinRSAKeyBits)
+	}
+
+	return rsa.GenerateKey(rand.Reader, bits)
+}
+
+// GenerateECKey generates an EC keypair on P-256, P-384 or P-521, the
+// curves of RFC 7518 section 6.2.1.1, for the ES* algorithms and ECDH-ES
+// key agreement.
+func GenerateECKey(curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
+	switch curve {
+	case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	}
+
+	return nil, errors.New("Unsupported elliptic curve")
+}
+
+// GenerateEd25519Key generates an Ed25519 keypair (RFC 8037) for the EdDSA
+// algorithm.
+func GenerateEd25519Key() (ed25519.PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	return key, err
+}
+
+// EncodePrivateKeyPEM encodes the private key as a PKCS#8 "PRIVATE KEY"
+// PEM block.
+func EncodePrivateKeyPEM(key crypto.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// EncryptPrivateKeyPEM encodes the private key as an encrypted PKCS#8
+// "ENCRYPTED PRIVATE KEY" PEM block, using PBES2 with PBKDF2-HMAC-SHA256
+// and AES-256-CBC. The result can be read back with ParsePrivateKey and
+// OpenSSL.
+func EncryptPrivateKeyPEM(key crypto.PrivateKey, password []byte) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, errors.New("Password required to encrypt private key")
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err = encryptPKCS8(der, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der}), nil
+}
+
+// EncodePublicKeyPEM encodes the public key as a SubjectPublicKeyInfo
+// "PUBLIC KEY" PEM block. A private key may be given, in which case its
+// public half is encoded.
+func EncodePublicKeyPEM(key interface{}) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// publicKey returns the public half of a private key, or the key itself.
+func publicKey(key interface{}) interface{} {
+	if k, ok := key.(crypto.Signer); ok {
+		return k.Public()
+	}
+	return key
+}
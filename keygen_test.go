@@ -0,0 +1,161 @@
+package gojwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+)
+
+func TestGenerateKey(t *testing.T) {
+	for _, id := range []uint{HS256, HS512, RS256, RS512, PS256} {
+		key, err := GenerateKey(id)
+		if err != nil {
+			t.Fatalf("[%d] %v", id, err)
+		}
+
+		token := NewToken(id, &IanaClaims{Subject: "1234567890"})
+		if err := token.Sign(key); err != nil {
+			t.Fatalf("[%d] %v", id, err)
+		}
+
+		var verifyKey interface{} = key
+		if k, ok := key.(*rsa.PrivateKey); ok {
+			if k.N.BitLen() != MinRSAKeyBits {
+				t.Errorf("[%d] got '%v' want '%v'", id, k.N.BitLen(), MinRSAKeyBits)
+			}
+			verifyKey = &k.PublicKey
+		} else if got, want := len(key.([]byte)), SignMethodTable[id].Method.Alg().Size(); got != want {
+			t.Errorf("[%d] got '%v' want '%v'", id, got, want)
+		}
+
+		if err := token.Verify(verifyKey); err != nil {
+			t.Errorf("[%d] %v", id, err)
+		}
+	}
+
+	if _, err := GenerateKey(0); err == nil {
+		t.Error("Expected error for unknown algorithm")
+	}
+}
+
+func TestGenerateKeyMinimum(t *testing.T) {
+	if _, err := GenerateRSAKey(1024); err == nil {
+		t.Error("Expected error for 1024 bit RSA key")
+	}
+
+	if _, err := GenerateHMACKey(crypto.SHA512, 32); err == nil {
+		t.Error("Expected error for short HMAC key")
+	}
+}
+
+func TestGenerateECKey(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		key, err := GenerateECKey(curve)
+		if err != nil {
+			t.Fatalf("[%s] %v", curve.Params().Name, err)
+		}
+		if key.Curve != curve {
+			t.Errorf("got '%v' want '%v'", key.Curve.Params().Name, curve.Params().Name)
+		}
+
+		token := NewEncryptedToken(ECDH_ES_A128KW, A128GCM, &IanaClaims{Subject: "1234567890"})
+		if err := token.Encrypt(&key.PublicKey); err != nil {
+			t.Fatalf("[%s] %v", curve.Params().Name, err)
+		}
+		claims := &IanaClaims{}
+		parsed := NewEncryptedToken(ECDH_ES_A128KW, A128GCM, claims)
+		if err := parsed.Parse(token.Value); err != nil {
+			t.Fatal(err)
+		}
+		if err := parsed.Decrypt(key); err != nil {
+			t.Errorf("[%s] %v", curve.Params().Name, err)
+		}
+	}
+
+	if _, err := GenerateECKey(elliptic.P224()); err == nil {
+		t.Error("Expected error for P-224 curve")
+	}
+}
+
+func TestGenerateEd25519Key(t *testing.T) {
+	key, err := GenerateEd25519Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := EncodePrivateKeyPEM(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePrivateKey(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwk, err := NewJWK(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := jwk.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := []byte("eyJhbGciOiJFZERTQSJ9.eyJzdWIiOiIxMjM0NTY3ODkwIn0")
+	sig := ed25519.Sign(parsed.(ed25519.PrivateKey), msg)
+	if !ed25519.Verify(pub.(ed25519.PublicKey), msg, sig) {
+		t.Error("Expected valid signature")
+	}
+	if ed25519.Verify(pub.(ed25519.PublicKey), append(msg, '.'), sig) {
+		t.Error("Expected invalid signature")
+	}
+}
+
+func TestEncodeKeyPEM(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := EncodePrivateKeyPEM(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParsePrivateKey(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Equal(got) {
+		t.Error("Private key mismatch")
+	}
+
+	data, err = EncryptPrivateKeyPEM(key, []byte("mysecret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParsePrivateKey(data, []byte("wrongsecret")); err == nil {
+		t.Error("Expected error for wrong password")
+	}
+	got, err = ParsePrivateKey(data, []byte("mysecret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Equal(got) {
+		t.Error("Encrypted private key mismatch")
+	}
+
+	data, err = EncodePublicKeyPEM(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParsePublicKey(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.PublicKey.Equal(pub) {
+		t.Error("Public key mismatch")
+	}
+}
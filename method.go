@@ -45,3 +45,14 @@ type SignMethod interface {
 	Sign(string, interface{}) (string, error)
 	Alg() crypto.Hash
 }
+
+// lookupSignMethod returns the signing method registered for the "alg"
+// header value.
+func lookupSignMethod(alg string) SignMethod {
+	for _, data := range SignMethodTable {
+		if data.Method != nil && data.Header["alg"] == alg {
+			return data.Method
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package gojwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of the context carrying the verified token.
+func NewContext(ctx context.Context, token *Token) context.Context {
+	return context.WithValue(ctx, contextKey{}, token)
+}
+
+// FromContext returns the verified token stored in the context by the
+// middleware.
+func FromContext(ctx context.Context) (*Token, bool) {
+	token, ok := ctx.Value(contextKey{}).(*Token)
+	return token, ok
+}
+
+// Verifier verifies tokens: the token is parsed, its signature verified
+// with the key returned by KeyFunc and its claims validated.
+type Verifier struct {
+	// Method is the expected signing method (HS256, RS256, ...). Tokens
+	// signed with another algorithm are rejected.
+	Method uint
+
+	// KeyFunc returns the verification key of the token.
+	KeyFunc KeyFunc
+
+	// NewClaims returns the claims a token is decoded into, once per
+	// token.
+	NewClaims func() Claims
+
+	// Parser holds additional parsing checks. Optional.
+	Parser *Parser
+
+	// ReplayCache makes tokens usable once. It is consulted after the
+	// signature is verified, so that forged tokens cannot burn the jti of
+	// genuine ones. Optional.
+	ReplayCache *ReplayCache
+}
+
+// Verify parses and verifies the token string and returns the verified
+// token.
+func (v *Verifier) Verify(tokenString string) (*Token, error) {
+	if v.KeyFunc == nil || v.NewClaims == nil {
+		return nil, errors.New("Verifier requires KeyFunc and NewClaims")
+	}
+
+	parser := v.Parser
+	if parser == nil {
+		parser = &Parser{}
+	}
+
+	token := NewToken(v.Method, v.NewClaims())
+	want := token.Header["alg"]
+	if err := parser.Parse(token, tokenString, false); err != nil {
+		return nil, err
+	}
+	if token.Header["alg"] != want {
+		return nil, &TokenError{
+			Text:  fmt.Errorf("Unexpected signing algorithm in the header: %v", token.Header["alg"]),
+			Flags: ErrorInvalidToken,
+		}
+	}
+
+	key, err := v.KeyFunc(token)
+	if err != nil {
+		return nil, &TokenError{Text: err, Flags: ErrorInvalidToken}
+	}
+	if err := token.Verify(key); err != nil {
+		return nil, &TokenError{Text: err, Flags: ErrorIvalidSignature}
+	}
+	if err := token.Validate(); err != nil {
+		return nil, err
+	}
+
+	if v.ReplayCache != nil {
+		if err := v.ReplayCache.Check(token); err != nil {
+			return nil, err
+		}
+	}
+
+	return token, nil
+}
+
+// Middleware authenticates requests with bearer tokens. The token is
+// extracted from the request and checked by the Verifier. The verified
+// token is stored in the request context, see FromContext. Rejected
+// requests are answered with a 401 and a WWW-Authenticate challenge
+// (RFC 6750 section 3), or handed to ErrorHandler when set.
+type Middleware struct {
+	Verifier
+
+	// Extractor defaults to BearerExtractor.
+	Extractor Extractor
+
+	// Realm is the realm of the WWW-Authenticate challenge. Optional.
+	Realm string
+
+	// ErrorHandler replaces the default 401 response. Optional.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Handler returns a handler authenticating requests before calling next.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := m.Authenticate(r)
+		if err != nil {
+			if m.ErrorHandler != nil {
+				m.ErrorHandler(w, r, err)
+			} else {
+				m.writeError(w, err)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), token)))
+	})
+}
+
+// Authenticate extracts and verifies the token of the request. Extraction
+// failures are returned as *ExtractError.
+func (m *Middleware) Authenticate(r *http.Request) (*Token, error) {
+	extractor := m.Extractor
+	if extractor == nil {
+		extractor = BearerExtractor
+	}
+	tokenString, err := extractor.Extract(r)
+	if err != nil {
+		return nil, &ExtractError{Err: err}
+	}
+
+	return m.Verify(tokenString)
+}
+
+// ExtractError is returned by Authenticate when the token could not be
+// extracted from the request.
+type ExtractError struct {
+	Err error
+}
+
+func (e *ExtractError) Error() string {
+	return e.Err.Error()
+}
+
+// writeError writes the RFC 6750 challenge. Requests without a token get
+// no error code, malformed requests get invalid_request and rejected
+// tokens invalid_token.
+func (m *Middleware) writeError(w http.ResponseWriter, err error) {
+	params := []string{}
+	if m.Realm != "" {
+		params = append(params, fmt.Sprintf("realm=%q", m.Realm))
+	}
+
+	status := http.StatusUnauthorized
+	if e, ok := err.(*ExtractError); ok {
+		if e.Err != ErrNoToken {
+			status = http.StatusBadRequest
+			params = append(params, `error="invalid_request"`,
+				`error_description="The token is malformed"`)
+		}
+	} else {
+		params = append(params, `error="invalid_token"`,
+			fmt.Sprintf("error_description=%q", errorDescription(err)))
+	}
+
+	challenge := "Bearer"
+	if len(params) > 0 {
+		challenge += " " + strings.Join(params, ", ")
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, http.StatusText(status), status)
+}
+
+// errorDescription describes the rejection from the TokenError flags,
+// without exposing the details of the error.
+func errorDescription(err error) string {
+	e, ok := err.(*TokenError)
+	if !ok {
+		return "The token is invalid"
+	}
+
+	switch {
+	case e.Flags&ErrorIvalidSignature != 0:
+		return "The token signature is invalid"
+	case e.Flags&ErrorInvalidExpiration != 0:
+		return "The token expired"
+	case e.Flags&ErrorInvalidNotBefore != 0:
+		return "The token is not valid yet"
+	case e.Flags&ErrorInvalidIssuedAt != 0:
+		return "The token was issued in the future"
+	case e.Flags&ErrorInvalidIssuer != 0:
+		return "The token issuer is invalid"
+	case e.Flags&ErrorInvalidAudience != 0:
+		return "The token audience is invalid"
+	case e.Flags&ErrorInvalidJti != 0:
+		return "The token identifier is invalid"
+	}
+	return "The token is invalid"
+}
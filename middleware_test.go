@@ -0,0 +1,109 @@
+package gojwt
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := &Middleware{
+		Verifier: Verifier{
+			Method:    HS256,
+			KeyFunc:   func(*Token) (interface{}, error) { return testHMACKey, nil },
+			NewClaims: func() Claims { return &IanaClaims{} },
+		},
+		Realm: "example",
+	}
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := FromContext(r.Context())
+		if !ok {
+			t.Error("Missing token in context")
+			return
+		}
+		w.Write([]byte(token.Payload.(*IanaClaims).Subject))
+	}))
+
+	for i, data := range []struct {
+		auth       string
+		wantStatus int
+		wantBody   string
+		wantAuth   string
+	}{
+		{"Bearer " + signTestToken(t, &IanaClaims{Subject: "alice"}), 200, "alice", ""},
+		{"bearer " + signTestToken(t, &IanaClaims{Subject: "bob"}), 200, "bob", ""},
+		{"", 401, "", `Bearer realm="example"`},
+		{"Basic YWxpY2U6c2VjcmV0", 401, "", `Bearer realm="example"`},
+		{"Bearer abc def", 400, "", `error="invalid_request"`},
+		{"Bearer " + signTestTokenWith(t, NewToken(HS512, &IanaClaims{}), bytes.Repeat(testHMACKey, 2)),
+			401, "", `error="invalid_token"`},
+		{"Bearer " + signTestToken(t, &IanaClaims{ExpiresAt: time.Now().Add(-time.Minute).Unix()}), 401, "",
+			`error_description="The token expired"`},
+		{"Bearer " + signTestToken(t, &IanaClaims{})[:40], 401, "", `error="invalid_token"`},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		if data.auth != "" {
+			r.Header.Set("Authorization", data.auth)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != data.wantStatus {
+			t.Errorf("[%d] got '%v' want '%v'", i, w.Code, data.wantStatus)
+		}
+		if data.wantBody != "" && w.Body.String() != data.wantBody {
+			t.Errorf("[%d] got '%v' want '%v'", i, w.Body.String(), data.wantBody)
+		}
+		got := w.Header().Get("WWW-Authenticate")
+		if !strings.Contains(got, data.wantAuth) {
+			t.Errorf("[%d] got '%v' want '%v'", i, got, data.wantAuth)
+		}
+		// Requests without a bearer token get a challenge without error code
+		if data.auth == "" || strings.HasPrefix(data.auth, "Basic") {
+			if got != data.wantAuth {
+				t.Errorf("[%d] got '%v' want '%v'", i, got, data.wantAuth)
+			}
+		}
+	}
+}
+
+func TestMiddlewareSignature(t *testing.T) {
+	forged := signTestTokenWith(t, NewToken(HS256, &IanaClaims{}), []byte("another key of thirty two bytes!"))
+
+	var gotErr error
+	m := &Middleware{
+		Verifier: Verifier{
+			Method:    HS256,
+			KeyFunc:   func(*Token) (interface{}, error) { return testHMACKey, nil },
+			NewClaims: func() Claims { return &IanaClaims{} },
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			gotErr = err
+			w.WriteHeader(http.StatusTeapot)
+		},
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+forged)
+	w := httptest.NewRecorder()
+	m.Handler(http.NotFoundHandler()).ServeHTTP(w, r)
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("got '%v' want '%v'", w.Code, http.StatusTeapot)
+	}
+	if e, ok := gotErr.(*TokenError); !ok || e.Flags&ErrorIvalidSignature == 0 {
+		t.Errorf("got '%v' want signature error", gotErr)
+	}
+
+	// Key resolution failures reject the token
+	m.KeyFunc = func(*Token) (interface{}, error) { return nil, errors.New("Unknown kid") }
+	m.ErrorHandler = nil
+	w = httptest.NewRecorder()
+	m.Handler(http.NotFoundHandler()).ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("got '%v' want '%v'", w.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,195 @@
+package gojwt
+
+import (
+	"crypto"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Audience is the "aud" claim, a single string or an array of strings
+// (RFC 7519 section 4.1.3).
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return errors.New("Invalid aud claim")
+	}
+	*a = list
+	return nil
+}
+
+// MarshalJSON encodes a single audience as a string.
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// IDTokenClaims are the claims of an OpenID Connect ID token (OpenID
+// Connect Core 1.0 section 2).
+type IDTokenClaims struct {
+	IanaClaims
+
+	// Audience replaces the audience of IanaClaims, ID tokens may have
+	// several audiences.
+	Audience Audience `json:"aud,omitempty"`
+
+	Nonce    string   `json:"nonce,omitempty"`
+	AuthTime int64    `json:"auth_time,omitempty"`
+	Acr      string   `json:"acr,omitempty"`
+	Amr      []string `json:"amr,omitempty"`
+	Azp      string   `json:"azp,omitempty"`
+	AtHash   string   `json:"at_hash,omitempty"`
+	CHash    string   `json:"c_hash,omitempty"`
+	Sid      string   `json:"sid,omitempty"`
+}
+
+// IDTokenValidator validates ID tokens as specified by OpenID Connect Core
+// 1.0 section 3.1.3.7, once their signature is verified.
+type IDTokenValidator struct {
+	// Issuer is the issuer identifier of the provider, matched exactly.
+	Issuer string
+
+	// ClientID must be one of the audiences.
+	ClientID string
+
+	// TrustedAudiences are the audiences accepted besides ClientID.
+	TrustedAudiences []string
+
+	// Nonce is the value sent in the authentication request. Not checked
+	// when empty.
+	Nonce string
+
+	// MaxAge is the max_age sent in the authentication request; the
+	// token must then have an "auth_time" no older. Not checked when
+	// zero.
+	MaxAge time.Duration
+
+	// Leeway tolerates clock skew in the MaxAge check.
+	Leeway time.Duration
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// Validate validates the claims of a verified ID token, which must have
+// been parsed into *IDTokenClaims.
+func (v *IDTokenValidator) Validate(token *Token) error {
+	c, ok := token.Payload.(*IDTokenClaims)
+	if !ok {
+		return errors.New("The token payload is not *IDTokenClaims")
+	}
+
+	if err := c.Valid(); err != nil {
+		return err
+	}
+	if c.ExpiresAt == 0 {
+		return &TokenError{Text: errors.New("Missing exp"), Flags: ErrorInvalidExpiration}
+	}
+	if c.IssuedAt == 0 {
+		return &TokenError{Text: errors.New("Missing iat"), Flags: ErrorInvalidIssuedAt}
+	}
+
+	if c.Issuer != v.Issuer {
+		return &TokenError{Text: fmt.Errorf("Unexpected issuer %q", c.Issuer), Flags: ErrorInvalidIssuer}
+	}
+
+	if !c.Audience.Contains(v.ClientID) {
+		return &TokenError{Text: errors.New("The client is not an audience of the token"), Flags: ErrorInvalidAudience}
+	}
+	for _, aud := range c.Audience {
+		if aud != v.ClientID && !Audience(v.TrustedAudiences).Contains(aud) {
+			return &TokenError{Text: fmt.Errorf("Untrusted audience %q", aud), Flags: ErrorInvalidAudience}
+		}
+	}
+	if len(c.Audience) > 1 && c.Azp == "" {
+		return &TokenError{Text: errors.New("Missing azp with multiple audiences"), Flags: ErrorInvalidAudience}
+	}
+	if c.Azp != "" && c.Azp != v.ClientID {
+		return &TokenError{Text: fmt.Errorf("Unexpected authorized party %q", c.Azp), Flags: ErrorInvalidAudience}
+	}
+
+	if v.Nonce != "" && subtle.ConstantTimeCompare([]byte(c.Nonce), []byte(v.Nonce)) != 1 {
+		return &TokenError{Text: errors.New("Nonce mismatch"), Flags: ErrorInvalidClaim}
+	}
+
+	if v.MaxAge != 0 {
+		now := time.Now()
+		if v.now != nil {
+			now = v.now()
+		}
+		if c.AuthTime == 0 {
+			return &TokenError{Text: errors.New("Missing auth_time"), Flags: ErrorInvalidClaim}
+		}
+		if now.Sub(time.Unix(c.AuthTime, 0)) > v.MaxAge+v.Leeway {
+			return &TokenError{Text: errors.New("Authentication too old"), Flags: ErrorInvalidClaim}
+		}
+	}
+
+	return nil
+}
+
+// VerifyAccessToken checks the "at_hash" claim of a verified ID token
+// against the access token issued with it.
+func (v *IDTokenValidator) VerifyAccessToken(token *Token, accessToken string) error {
+	c, ok := token.Payload.(*IDTokenClaims)
+	if !ok {
+		return errors.New("The token payload is not *IDTokenClaims")
+	}
+	return verifyTokenHash(token, c.AtHash, accessToken, "at_hash")
+}
+
+// VerifyCode checks the "c_hash" claim of a verified ID token against the
+// authorization code issued with it.
+func (v *IDTokenValidator) VerifyCode(token *Token, code string) error {
+	c, ok := token.Payload.(*IDTokenClaims)
+	if !ok {
+		return errors.New("The token payload is not *IDTokenClaims")
+	}
+	return verifyTokenHash(token, c.CHash, code, "c_hash")
+}
+
+func verifyTokenHash(token *Token, claim, value, name string) error {
+	if claim == "" {
+		return &TokenError{Text: fmt.Errorf("Missing %s", name), Flags: ErrorInvalidClaim}
+	}
+	if token.Method == nil {
+		return errors.New("Missing signing method")
+	}
+
+	want := TokenHash(token.Method.Alg(), value)
+	if subtle.ConstantTimeCompare([]byte(claim), []byte(want)) != 1 {
+		return &TokenError{Text: fmt.Errorf("Invalid %s", name), Flags: ErrorInvalidClaim}
+	}
+	return nil
+}
+
+// TokenHash returns the base64url encoded left half of the hash of the
+// value, as used by the "at_hash" and "c_hash" claims. The hash is the one
+// of the signing algorithm of the ID token.
+func TokenHash(hash crypto.Hash, value string) string {
+	h := hash.New()
+	h.Write([]byte(value))
+	sum := h.Sum(nil)
+	return EncodeSegment(sum[:len(sum)/2])
+}
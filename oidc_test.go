@@ -0,0 +1,156 @@
+package gojwt
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAudience(t *testing.T) {
+	for i, data := range []struct {
+		json string
+		want Audience
+	}{
+		{`{"aud":"a"}`, Audience{"a"}},
+		{`{"aud":["a","b"]}`, Audience{"a", "b"}},
+		{`{}`, nil},
+	} {
+		var c IDTokenClaims
+		if err := json.Unmarshal([]byte(data.json), &c); err != nil {
+			t.Fatalf("[%d] %v", i, err)
+		}
+		if len(c.Audience) != len(data.want) || (len(c.Audience) > 0 && c.Audience[0] != data.want[0]) {
+			t.Errorf("[%d] got '%v' want '%v'", i, c.Audience, data.want)
+		}
+
+		b, _ := json.Marshal(&c)
+		var again IDTokenClaims
+		json.Unmarshal(b, &again)
+		if len(again.Audience) != len(data.want) {
+			t.Errorf("[%d] got '%s' want '%v'", i, b, data.want)
+		}
+	}
+
+	var c IDTokenClaims
+	if err := json.Unmarshal([]byte(`{"aud":1}`), &c); err == nil {
+		t.Error("Expected error for invalid aud")
+	}
+}
+
+func TestIDTokenValidator(t *testing.T) {
+	now := time.Now()
+	valid := func() *IDTokenClaims {
+		return &IDTokenClaims{
+			IanaClaims: IanaClaims{
+				Issuer:    "https://accounts.example.com",
+				Subject:   "alice",
+				IssuedAt:  now.Unix(),
+				ExpiresAt: now.Add(time.Hour).Unix(),
+			},
+			Audience: Audience{"client"},
+			Nonce:    "n-0S6_WzA2Mj",
+			AuthTime: now.Add(-time.Minute).Unix(),
+		}
+	}
+
+	v := &IDTokenValidator{
+		Issuer:           "https://accounts.example.com",
+		ClientID:         "client",
+		TrustedAudiences: []string{"api"},
+		Nonce:            "n-0S6_WzA2Mj",
+		MaxAge:           5 * time.Minute,
+	}
+
+	for i, data := range []struct {
+		modify    func(c *IDTokenClaims)
+		wantFlags uint32
+	}{
+		{func(c *IDTokenClaims) {}, 0},
+		{func(c *IDTokenClaims) { c.Audience = Audience{"client", "api"}; c.Azp = "client" }, 0},
+		{func(c *IDTokenClaims) { c.Issuer = "https://evil.example.com" }, ErrorInvalidIssuer},
+		{func(c *IDTokenClaims) { c.Audience = Audience{"other"} }, ErrorInvalidAudience},
+		{func(c *IDTokenClaims) { c.Audience = Audience{"client", "unknown"}; c.Azp = "client" }, ErrorInvalidAudience},
+		{func(c *IDTokenClaims) { c.Audience = Audience{"client", "api"} }, ErrorInvalidAudience},
+		{func(c *IDTokenClaims) { c.Azp = "api" }, ErrorInvalidAudience},
+		{func(c *IDTokenClaims) { c.Nonce = "replayed" }, ErrorInvalidClaim},
+		{func(c *IDTokenClaims) { c.AuthTime = 0 }, ErrorInvalidClaim},
+		{func(c *IDTokenClaims) { c.AuthTime = now.Add(-time.Hour).Unix() }, ErrorInvalidClaim},
+		{func(c *IDTokenClaims) { c.ExpiresAt = now.Add(-time.Minute).Unix() }, ErrorInvalidExpiration},
+		{func(c *IDTokenClaims) { c.ExpiresAt = 0 }, ErrorInvalidExpiration},
+		{func(c *IDTokenClaims) { c.IssuedAt = 0 }, ErrorInvalidIssuedAt},
+	} {
+		claims := valid()
+		data.modify(claims)
+
+		token := NewToken(RS256, claims)
+		err := v.Validate(token)
+		var got uint32
+		if e, ok := err.(*TokenError); ok {
+			got = e.Flags
+		} else if err != nil {
+			t.Errorf("[%d] %v", i, err)
+		}
+		if got != data.wantFlags {
+			t.Errorf("[%d] got '%v' want '%v'", i, got, data.wantFlags)
+		}
+	}
+}
+
+func TestIDTokenHashes(t *testing.T) {
+	key, err := ParseRSAPrivateKey([]byte(testVectorRSA[0].pemData), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessToken := "jHkWEdUXMU1BwAsC4vtUsZwnNUKFx23cJpBb0U2Qhd4"
+	code := "Qcb0Orv1zh30vL1MPRsbm-diHiMwcLyZvn1arpZv-Jxf_11jnpEX3Tgfvk"
+
+	sum := sha256.Sum256([]byte(accessToken))
+	if got, want := TokenHash(crypto.SHA256, accessToken), EncodeSegment(sum[:16]); got != want {
+		t.Errorf("got '%v' want '%v'", got, want)
+	}
+
+	token := NewToken(RS256, &IDTokenClaims{
+		IanaClaims: IanaClaims{
+			Issuer:    "https://accounts.example.com",
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		},
+		Audience: Audience{"client"},
+		AtHash:   TokenHash(crypto.SHA256, accessToken),
+		CHash:    TokenHash(crypto.SHA256, code),
+	})
+	if err := token.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+
+	claims := &IDTokenClaims{}
+	parsed := NewToken(RS256, claims)
+	if err := parsed.Parse(token.Value, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := parsed.Verify(&key.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	v := &IDTokenValidator{Issuer: "https://accounts.example.com", ClientID: "client"}
+	if err := v.Validate(parsed); err != nil {
+		t.Error(err)
+	}
+	if err := v.VerifyAccessToken(parsed, accessToken); err != nil {
+		t.Error(err)
+	}
+	if err := v.VerifyCode(parsed, code); err != nil {
+		t.Error(err)
+	}
+	if err := v.VerifyAccessToken(parsed, code); err == nil {
+		t.Error("Expected error for wrong access token")
+	}
+
+	// The hash follows the signing algorithm
+	parsed.Method = SignMethodTable[RS512].Method
+	if err := v.VerifyCode(parsed, code); err == nil {
+		t.Error("Expected error for hash of another algorithm")
+	}
+}
@@ -0,0 +1,276 @@
+package gojwt
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrRefreshTokenReused is returned when a refresh token is presented
+	// a second time. The whole family has been revoked.
+	ErrRefreshTokenReused = errors.New("Refresh token reused, token family revoked")
+
+	// ErrRefreshTokenRevoked is returned for refresh tokens unknown to the
+	// store or belonging to a revoked family.
+	ErrRefreshTokenRevoked = errors.New("Refresh token revoked")
+)
+
+// Token types of the pairs, set in the "typ" header
+const (
+	AccessTokenType  = "at+jwt"
+	RefreshTokenType = "refresh+jwt"
+)
+
+// RefreshClaims are the claims of refresh tokens. All the refresh tokens
+// obtained from a login by rotation belong to the same family.
+type RefreshClaims struct {
+	IanaClaims
+
+	// Family identifies the chain of refresh tokens
+	Family string `json:"fam"`
+
+	// AccessJti is the jti of the access token issued with this refresh
+	// token
+	AccessJti string `json:"ati"`
+}
+
+// TokenPair is a short-lived access token and the refresh token used to
+// obtain the next pair.
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
+// RefreshStore records the refresh tokens of every family to detect reuse.
+type RefreshStore interface {
+	// Save records an unused refresh token of the family, until
+	// expiresAt.
+	Save(family, jti string, expiresAt time.Time) error
+
+	// Use atomically marks the refresh token used. It returns
+	// ErrRefreshTokenReused when already used and ErrRefreshTokenRevoked
+	// when unknown or when the family is revoked.
+	Use(family, jti string) error
+
+	// RevokeFamily revokes every refresh token of the family.
+	RevokeFamily(family string) error
+}
+
+// PairIssuer issues access and refresh token pairs and rotates refresh
+// tokens: each refresh token can be exchanged once for a new pair, and
+// presenting it again revokes the whole family, as the token has likely
+// been stolen.
+type PairIssuer struct {
+	// Method is the signing method (HS256, RS256, ...) and Key the
+	// signing key. Refresh tokens are verified with Key, or with its
+	// public half for asymmetric keys.
+	Method uint
+	Key    interface{}
+
+	Issuer string
+
+	// AccessLifetime defaults to 15 minutes and RefreshLifetime to 30
+	// days.
+	AccessLifetime  time.Duration
+	RefreshLifetime time.Duration
+
+	// AccessClaims returns the claims of an access token from the
+	// registered claims filled in by the issuer. Optional.
+	AccessClaims func(claims IanaClaims) Claims
+
+	Store RefreshStore
+}
+
+// Issue issues the first pair of a new family for the subject.
+func (p *PairIssuer) Issue(subject string) (*TokenPair, error) {
+	family, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	return p.issue(subject, family)
+}
+
+// Refresh verifies the refresh token and exchanges it for a new pair of
+// the same family.
+func (p *PairIssuer) Refresh(refreshToken string) (*TokenPair, error) {
+	claims := &RefreshClaims{}
+	v := &Verifier{
+		Method:    p.Method,
+		KeyFunc:   func(*Token) (interface{}, error) { return publicKey(p.Key), nil },
+		NewClaims: func() Claims { return claims },
+		Parser:    &Parser{ExpectedType: RefreshTokenType},
+	}
+	if _, err := v.Verify(refreshToken); err != nil {
+		return nil, err
+	}
+	if claims.Family == "" || claims.Jti == "" || claims.Issuer != p.Issuer {
+		return nil, &TokenError{Text: errors.New("Invalid refresh token"), Flags: ErrorInvalidClaim}
+	}
+
+	if err := p.Store.Use(claims.Family, claims.Jti); err != nil {
+		if err == ErrRefreshTokenReused {
+			if err := p.Store.RevokeFamily(claims.Family); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	return p.issue(claims.Subject, claims.Family)
+}
+
+func (p *PairIssuer) issue(subject, family string) (*TokenPair, error) {
+	accessLifetime := p.AccessLifetime
+	if accessLifetime == 0 {
+		accessLifetime = 15 * time.Minute
+	}
+	refreshLifetime := p.RefreshLifetime
+	if refreshLifetime == 0 {
+		refreshLifetime = 30 * 24 * time.Hour
+	}
+
+	accessJti, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	refreshJti, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	pair := &TokenPair{
+		AccessExpiresAt:  now.Add(accessLifetime),
+		RefreshExpiresAt: now.Add(refreshLifetime),
+	}
+
+	iana := IanaClaims{
+		Issuer:    p.Issuer,
+		Subject:   subject,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: pair.AccessExpiresAt.Unix(),
+		Jti:       accessJti,
+	}
+	var claims Claims = &iana
+	if p.AccessClaims != nil {
+		claims = p.AccessClaims(iana)
+	}
+	access := NewTokenWithType(p.Method, AccessTokenType, claims)
+	if err := access.Sign(p.Key); err != nil {
+		return nil, err
+	}
+
+	iana.ExpiresAt = pair.RefreshExpiresAt.Unix()
+	iana.Jti = refreshJti
+	refresh := NewTokenWithType(p.Method, RefreshTokenType, &RefreshClaims{
+		IanaClaims: iana,
+		Family:     family,
+		AccessJti:  accessJti,
+	})
+	if err := refresh.Sign(p.Key); err != nil {
+		return nil, err
+	}
+
+	if err := p.Store.Save(family, refreshJti, pair.RefreshExpiresAt); err != nil {
+		return nil, err
+	}
+
+	pair.AccessToken = access.Value
+	pair.RefreshToken = refresh.Value
+	return pair, nil
+}
+
+// randomID returns a random 128-bit identifier, base64url encoded.
+func randomID() (string, error) {
+	b, err := randomKey(16)
+	if err != nil {
+		return "", err
+	}
+	return EncodeSegment(b), nil
+}
+
+// MemoryRefreshStore is a RefreshStore kept in memory, for single node
+// deployments and tests. Families are dropped once all their tokens
+// expired.
+type MemoryRefreshStore struct {
+	mu       sync.Mutex
+	families map[string]*refreshFamily
+	pruned   time.Time
+}
+
+type refreshFamily struct {
+	revoked   bool
+	used      map[string]bool
+	expiresAt time.Time
+}
+
+func NewMemoryRefreshStore() *MemoryRefreshStore {
+	return &MemoryRefreshStore{families: make(map[string]*refreshFamily)}
+}
+
+func (s *MemoryRefreshStore) Save(family, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(time.Now())
+
+	f, ok := s.families[family]
+	if !ok {
+		f = &refreshFamily{used: make(map[string]bool)}
+		s.families[family] = f
+	}
+	if f.revoked {
+		return ErrRefreshTokenRevoked
+	}
+	f.used[jti] = false
+	if expiresAt.After(f.expiresAt) {
+		f.expiresAt = expiresAt
+	}
+	return nil
+}
+
+func (s *MemoryRefreshStore) Use(family, jti string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.families[family]
+	if !ok || f.revoked {
+		return ErrRefreshTokenRevoked
+	}
+	used, ok := f.used[jti]
+	switch {
+	case !ok:
+		return ErrRefreshTokenRevoked
+	case used:
+		return ErrRefreshTokenReused
+	}
+	f.used[jti] = true
+	return nil
+}
+
+func (s *MemoryRefreshStore) RevokeFamily(family string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f, ok := s.families[family]; ok {
+		f.revoked = true
+	}
+	return nil
+}
+
+// prune drops the expired families, at most once a minute.
+func (s *MemoryRefreshStore) prune(now time.Time) {
+	if now.Sub(s.pruned) < time.Minute {
+		return
+	}
+	s.pruned = now
+
+	for name, f := range s.families {
+		if now.After(f.expiresAt) {
+			delete(s.families, name)
+		}
+	}
+}
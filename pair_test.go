@@ -0,0 +1,117 @@
+package gojwt
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestPairIssuer(t *testing.T) {
+	key, err := ParseRSAPrivateKey([]byte(testVectorRSA[0].pemData), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &PairIssuer{
+		Method: RS256,
+		Key:    key,
+		Issuer: "auth",
+		Store:  NewMemoryRefreshStore(),
+	}
+
+	first, err := p.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The access token is typed and linked to the refresh token
+	access := &IanaClaims{}
+	token := NewToken(RS256, access)
+	if err := (&Parser{ExpectedType: AccessTokenType}).Parse(token, first.AccessToken, true); err != nil {
+		t.Fatal(err)
+	}
+	refresh := &RefreshClaims{}
+	if err := (&Parser{ExpectedType: RefreshTokenType}).Parse(NewToken(RS256, refresh), first.RefreshToken, true); err != nil {
+		t.Fatal(err)
+	}
+	if access.Subject != "alice" || refresh.AccessJti != access.Jti || refresh.Jti == access.Jti {
+		t.Errorf("got '%+v' '%+v'", access, refresh)
+	}
+
+	// Refresh tokens are not accepted as access tokens by default
+	v := &Verifier{
+		Method:    RS256,
+		KeyFunc:   func(*Token) (interface{}, error) { return &key.PublicKey, nil },
+		NewClaims: func() Claims { return &IanaClaims{} },
+	}
+	if _, err := v.Verify(first.AccessToken); err != nil {
+		t.Error(err)
+	}
+	if _, err := v.Verify(first.RefreshToken); err == nil {
+		t.Error("Expected error for refresh token used as access token")
+	}
+
+	second, err := p.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := &RefreshClaims{}
+	if err := (&Parser{ExpectedType: RefreshTokenType}).Parse(NewToken(RS256, next), second.RefreshToken, true); err != nil {
+		t.Fatal(err)
+	}
+	if next.Family != refresh.Family || next.Subject != "alice" {
+		t.Errorf("got '%+v' want family '%v'", next, refresh.Family)
+	}
+
+	// Replaying the first refresh token revokes the family
+	if _, err := p.Refresh(first.RefreshToken); err != ErrRefreshTokenReused {
+		t.Errorf("got '%v' want '%v'", err, ErrRefreshTokenReused)
+	}
+	if _, err := p.Refresh(second.RefreshToken); err != ErrRefreshTokenRevoked {
+		t.Errorf("got '%v' want '%v'", err, ErrRefreshTokenRevoked)
+	}
+
+	// Other families are not affected
+	other, err := p.Issue("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Refresh(other.RefreshToken); err != nil {
+		t.Error(err)
+	}
+
+	// An access token is not a refresh token
+	if _, err := p.Refresh(other.AccessToken); err == nil {
+		t.Error("Expected error for access token")
+	}
+}
+
+func TestPairIssuerConcurrentRefresh(t *testing.T) {
+	p := &PairIssuer{
+		Method: HS256,
+		Key:    testHMACKey,
+		Store:  NewMemoryRefreshStore(),
+	}
+	pair, err := p.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.Refresh(pair.RefreshToken); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Errorf("got '%v' want '%v'", succeeded, 1)
+	}
+}
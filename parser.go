@@ -17,7 +17,36 @@ func DecodeSegment(seg string) ([]byte, error) {
 	return base64.URLEncoding.DecodeString(seg)
 }
 
+// EncodeSegment encodes data using base64url without padding.
+func EncodeSegment(data []byte) string {
+	return strings.TrimRight(base64.URLEncoding.EncodeToString(data), "=")
+}
+
+// Parser parses tokens with additional checks on top of Parse.
+type Parser struct {
+	// ExpectedType is the required "typ" header (RFC 8725 section 3.11),
+	// such as "at+jwt". Types are compared case-insensitively and the
+	// "application/" prefix may be omitted on either side. When empty,
+	// any type is accepted except RefreshTokenType, so that refresh tokens
+	// are never mistaken for access tokens.
+	ExpectedType string
+
+	// Revocation is consulted to deny revoked tokens. Optional.
+	Revocation Revocation
+
+	// CriticalHeaders lists the extension header parameters the
+	// application understands and processes, so that tokens listing them
+	// in "crit" are accepted. "b64" is always understood.
+	CriticalHeaders []string
+}
+
+// Parse decodes the token without verifying its signature and validates
+// the claims when validate is set.
 func Parse(token *Token, tokenString string, validate bool) error {
+	return (&Parser{}).Parse(token, tokenString, validate)
+}
+
+func (p *Parser) Parse(token *Token, tokenString string, validate bool) error {
 
 	parts := strings.Split(tokenString, ".")
 	if len(parts) != 3 {
@@ -28,9 +57,20 @@ func Parse(token *Token, tokenString string, validate bool) error {
 	if err != nil {
 		return fmt.Errorf("Invalid algorithm and token type segment: %v", err)
 	}
+	// Header values of the token being reused must not survive
+	token.Header = nil
 	if err = json.Unmarshal(seg, &token.Header); err != nil {
 		return fmt.Errorf("Unable to unmarshal header json data: %v", err)
 	}
+	if err = checkCritical(token.Header, p.CriticalHeaders); err != nil {
+		return err
+	}
+
+	if encoded, err := payloadEncoded(token.Header); err != nil {
+		return err
+	} else if !encoded {
+		return errors.New("The token has an unencoded payload")
+	}
 
 	seg, err = DecodeSegment(parts[1])
 	if err != nil {
@@ -59,6 +99,21 @@ func Parse(token *Token, tokenString string, validate bool) error {
 		return errors.New("Unsupported signing algorithm in the header")
 	}
 
+	typ, _ := token.Header["typ"].(string)
+	if p.ExpectedType != "" && !equalMediaType(typ, p.ExpectedType) ||
+		p.ExpectedType == "" && equalMediaType(typ, RefreshTokenType) {
+		return &TokenError{
+			Text:  fmt.Errorf("Unexpected token type %q", typ),
+			Flags: ErrorInvalidToken,
+		}
+	}
+
+	if p.Revocation != nil {
+		if err := checkRevocation(p.Revocation, seg); err != nil {
+			return err
+		}
+	}
+
 	if validate {
 		err = token.Validate()
 		if err != nil {
@@ -71,3 +126,16 @@ func Parse(token *Token, tokenString string, validate bool) error {
 	token.Value = tokenString
 	return nil
 }
+
+// equalMediaType compares two "typ" or "cty" values, where the
+// "application/" prefix may be omitted (RFC 7515 section 4.1.9).
+func equalMediaType(a, b string) bool {
+	return a != "" && strings.EqualFold(fullMediaType(a), fullMediaType(b))
+}
+
+func fullMediaType(typ string) string {
+	if strings.IndexByte(typ, '/') < 0 {
+		return "application/" + typ
+	}
+	return typ
+}
@@ -107,3 +107,41 @@ func TestParseAndValidate(t *testing.T) {
 		t.Error("Token is supposed to be valid...")
 	}
 }
+
+func TestParserExpectedType(t *testing.T) {
+	sign := func(token *Token) string {
+		return signTestTokenWith(t, token, testHMACKey)
+	}
+
+	access := sign(NewTokenWithType(HS256, "at+jwt", &IanaClaims{}))
+	prefixed := sign(NewTokenWithType(HS256, "application/AT+JWT", &IanaClaims{}))
+	id := sign(NewToken(HS256, &IanaClaims{}))
+	untyped := NewToken(HS256, &IanaClaims{})
+	delete(untyped.Header, "typ")
+
+	p := &Parser{ExpectedType: "at+jwt"}
+	for i, data := range []struct {
+		tokenString string
+		wantError   bool
+	}{
+		{access, false},
+		{prefixed, false},
+		{id, true},
+		{sign(untyped), true},
+	} {
+		// The token is reused, its header must not leak into the next parse
+		token := NewTokenWithType(HS256, "at+jwt", &IanaClaims{})
+		err := p.Parse(token, data.tokenString, true)
+		if got := err != nil; got != data.wantError {
+			t.Errorf("[%d] got '%v' want error '%v'", i, err, data.wantError)
+		}
+		if e, ok := err.(*TokenError); err != nil && (!ok || e.Flags&ErrorInvalidToken == 0) {
+			t.Errorf("[%d] got '%v' want invalid token error", i, err)
+		}
+	}
+
+	// Without expectation any type is accepted
+	if err := Parse(NewToken(HS256, &IanaClaims{}), access, true); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,255 @@
+package gojwt
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// Encrypted PKCS#8 private keys (RFC 5958) using the PBES2 scheme from
+// RFC 8018. These are the blocks labelled "ENCRYPTED PRIVATE KEY" produced
+// by `openssl genpkey` and `openssl pkcs8 -topk8`.
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA224 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 8}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+func pbkdf2PRF(id pkix.AlgorithmIdentifier) (func() hash.Hash, error) {
+	// hmacWithSHA1 is the default when the PRF is omitted
+	if len(id.Algorithm) == 0 {
+		return crypto.SHA1.New, nil
+	}
+
+	switch {
+	case id.Algorithm.Equal(oidHMACWithSHA1):
+		return crypto.SHA1.New, nil
+	case id.Algorithm.Equal(oidHMACWithSHA224):
+		return crypto.SHA224.New, nil
+	case id.Algorithm.Equal(oidHMACWithSHA256):
+		return crypto.SHA256.New, nil
+	case id.Algorithm.Equal(oidHMACWithSHA384):
+		return crypto.SHA384.New, nil
+	case id.Algorithm.Equal(oidHMACWithSHA512):
+		return crypto.SHA512.New, nil
+	}
+
+	return nil, errors.New("Unsupported PBKDF2 pseudorandom function")
+}
+
+func pbes2Cipher(id asn1.ObjectIdentifier) (func([]byte) (cipher.Block, error), int, error) {
+	switch {
+	case id.Equal(oidAES128CBC):
+		return aes.NewCipher, 16, nil
+	case id.Equal(oidAES192CBC):
+		return aes.NewCipher, 24, nil
+	case id.Equal(oidAES256CBC):
+		return aes.NewCipher, 32, nil
+	case id.Equal(oidDESEDE3CBC):
+		return des.NewTripleDESCipher, 24, nil
+	}
+
+	return nil, 0, errors.New("Unsupported PBES2 encryption scheme")
+}
+
+// decryptPKCS8 decrypts a DER encoded EncryptedPrivateKeyInfo and returns
+// the DER encoded PKCS#8 PrivateKeyInfo.
+func decryptPKCS8(der []byte, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, errors.New("Unsupported private key encryption algorithm, only PBES2 is supported")
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, errors.New("Unsupported PBES2 key derivation function")
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, err
+	}
+	if kdf.IterationCount < 1 {
+		return nil, errors.New("Invalid PBKDF2 iteration count")
+	}
+	if kdf.IterationCount > pkcs8MaxIterations {
+		return nil, fmt.Errorf("PBKDF2 iteration count %d exceeds %d", kdf.IterationCount, pkcs8MaxIterations)
+	}
+
+	prf, err := pbkdf2PRF(kdf.PRF)
+	if err != nil {
+		return nil, err
+	}
+
+	newCipher, keyLen, err := pbes2Cipher(params.EncryptionScheme.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if kdf.KeyLength != 0 && kdf.KeyLength != keyLen {
+		return nil, errors.New("Invalid PBKDF2 key length")
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+
+	block, err := newCipher(pbkdf2Key(password, kdf.Salt, kdf.IterationCount, keyLen, prf))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("Invalid PBES2 initialization vector")
+	}
+	data := info.EncryptedData
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, errors.New("Invalid encrypted private key length")
+	}
+
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+
+	out, err = pkcs7Unpad(out, block.BlockSize())
+	if err != nil {
+		return nil, errors.New("Failed to decrypt private key, invalid password")
+	}
+
+	return out, nil
+}
+
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, error) {
+	n := len(data)
+	if n == 0 || n%blockSize != 0 {
+		return nil, errors.New("Invalid padding")
+	}
+
+	pad := int(data[n-1])
+	if pad == 0 || pad > blockSize {
+		return nil, errors.New("Invalid padding")
+	}
+	for _, b := range data[n-pad:] {
+		if int(b) != pad {
+			return nil, errors.New("Invalid padding")
+		}
+	}
+
+	return data[:n-pad], nil
+}
+
+// pkcs8Iterations is the PBKDF2 iteration count used when encrypting
+// private keys. pkcs8MaxIterations bounds the count read from an encrypted
+// key, so that a crafted file cannot make decryption arbitrarily expensive.
+const (
+	pkcs8Iterations    = 600000
+	pkcs8MaxIterations = 10000000
+)
+
+// encryptPKCS8 encrypts a DER encoded PKCS#8 PrivateKeyInfo using PBES2
+// with PBKDF2-HMAC-SHA256 and AES-256-CBC, and returns the DER encoded
+// EncryptedPrivateKeyInfo.
+func encryptPKCS8(der []byte, password []byte) ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(pbkdf2Key(password, salt, pkcs8Iterations, 32, crypto.SHA256.New))
+	if err != nil {
+		return nil, err
+	}
+
+	pad := aes.BlockSize - len(der)%aes.BlockSize
+	data := make([]byte, len(der), len(der)+pad)
+	copy(data, der)
+	for i := 0; i < pad; i++ {
+		data = append(data, byte(pad))
+	}
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	kdf, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: pkcs8Iterations,
+		PRF: pkix.AlgorithmIdentifier{
+			Algorithm:  oidHMACWithSHA256,
+			Parameters: asn1.NullRawValue,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBKDF2,
+			Parameters: asn1.RawValue{FullBytes: kdf},
+		},
+		EncryptionScheme: pkix.AlgorithmIdentifier{
+			Algorithm:  oidAES256CBC,
+			Parameters: asn1.RawValue{FullBytes: ivParam},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBES2,
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		EncryptedData: data,
+	})
+}
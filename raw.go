@@ -0,0 +1,71 @@
+package gojwt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SignBytes signs arbitrary payload bytes, such as a protobuf message or a
+// CBOR document, and builds a compact token. The media type of the payload
+// is set in the "cty" header when contentType is not empty and the
+// default "typ" of JWT is removed, the payload not being JWT claims.
+// Payload is not used.
+func (t *Token) SignBytes(payload []byte, contentType string, key interface{}) error {
+	if t.Header == nil {
+		t.Header = make(map[string]interface{})
+	}
+	if contentType != "" {
+		t.Header["cty"] = contentType
+	}
+	if typ, _ := t.Header["typ"].(string); typ == "JWT" {
+		delete(t.Header, "typ")
+	}
+
+	if encoded, err := payloadEncoded(t.Header); err != nil {
+		return err
+	} else if !encoded {
+		return t.SignUnencoded(payload, key)
+	}
+
+	if err := t.SignDetached(payload, key); err != nil {
+		return err
+	}
+
+	t.Value = t.HeaderPayload + "." + t.Signature
+	return nil
+}
+
+// VerifyBytes verifies a compact token made by SignBytes and returns the
+// payload bytes. Its media type is found in the "cty" header. The "alg"
+// header must match the signing algorithm the token was created with.
+func (t *Token) VerifyBytes(tokenString string, key interface{}) ([]byte, error) {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("The token does not have 3 segments")
+	}
+
+	header, err := decodeHeader(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := payloadEncoded(header)
+	if err != nil {
+		return nil, err
+	}
+	if !encoded {
+		return t.VerifyUnencoded(tokenString, key)
+	}
+
+	payload, err := DecodeSegment(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid payload segment: %v", err)
+	}
+	if err := t.VerifyDetached(parts[0]+".."+parts[2], payload, key); err != nil {
+		return nil, err
+	}
+
+	t.HeaderPayload = parts[0] + "." + parts[1]
+	t.Value = tokenString
+	return payload, nil
+}
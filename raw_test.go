@@ -0,0 +1,52 @@
+package gojwt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSignBytes(t *testing.T) {
+	key, err := ParseRSAPrivateKey([]byte(testVectorRSA[0].pemData), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte{0x0a, 0x05, 'h', 'e', 'l', 'l', 'o', 0x10, 0x2a, '.'}
+
+	token := NewToken(PS256, nil)
+	if err := token.SignBytes(payload, "application/x-protobuf", key); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := token.Header["typ"]; ok {
+		t.Error("Unexpected typ header")
+	}
+
+	parsed := NewToken(PS256, nil)
+	got, err := parsed.VerifyBytes(token.Value, &key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Errorf("got '%x' want '%x'", got, payload)
+	}
+	if cty := parsed.Header["cty"]; cty != "application/x-protobuf" {
+		t.Errorf("got '%v' want '%v'", cty, "application/x-protobuf")
+	}
+
+	if _, err := NewToken(RS256, nil).VerifyBytes(token.Value, &key.PublicKey); err == nil {
+		t.Error("Expected error for unexpected signing algorithm")
+	}
+
+	// Unencoded payloads are signed as is
+	unencoded := NewToken(HS256, nil)
+	unencoded.SetUnencodedPayload()
+	if err := unencoded.SignBytes([]byte("hello"), "text/plain", testHMACKey); err != nil {
+		t.Fatal(err)
+	}
+	got, err = NewToken(HS256, nil).VerifyBytes(unencoded.Value, testHMACKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("got '%s' want '%s'", got, "hello")
+	}
+}
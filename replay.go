@@ -0,0 +1,109 @@
+package gojwt
+
+import (
+	"errors"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// replayShards is the number of independently locked parts of the cache
+const replayShards = 32
+
+// ReplayCache records the jti of one-time tokens, such as password reset
+// links, until they expire so that each can be used once. The cache is
+// sharded by jti to reduce lock contention.
+type ReplayCache struct {
+	shards [replayShards]replayShard
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+type replayShard struct {
+	mu     sync.Mutex
+	seen   map[string]int64
+	pruned int64
+}
+
+func NewReplayCache() *ReplayCache {
+	c := &ReplayCache{}
+	for i := range c.shards {
+		c.shards[i].seen = make(map[string]int64)
+	}
+	return c
+}
+
+// Use records the jti until expiresAt. It returns false when the jti was
+// already recorded and has not expired; checking and recording is atomic.
+func (c *ReplayCache) Use(jti string, expiresAt time.Time) bool {
+	h := fnv.New32a()
+	h.Write([]byte(jti))
+	shard := &c.shards[h.Sum32()%replayShards]
+
+	now := c.clock().Unix()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.prune(now)
+	if until, ok := shard.seen[jti]; ok && now < until {
+		return false
+	}
+	shard.seen[jti] = expiresAt.Unix()
+	return true
+}
+
+// Check records the jti of a verified token. Tokens without "jti" or
+// "exp" cannot be tracked and are rejected.
+func (c *ReplayCache) Check(token *Token) error {
+	claims, err := registeredClaims(token)
+	if err != nil {
+		return err
+	}
+	if claims.Jti == "" || claims.ExpiresAt == 0 {
+		return &TokenError{Text: errors.New("One-time tokens require jti and exp"), Flags: ErrorInvalidJti}
+	}
+
+	if !c.Use(claims.Jti, time.Unix(claims.ExpiresAt, 0)) {
+		return &TokenError{Text: errors.New("Token already used"), Flags: ErrorTokenReplayed}
+	}
+	return nil
+}
+
+// prune drops the expired entries of the shard, at most once a minute.
+func (s *replayShard) prune(now int64) {
+	if now-s.pruned < 60 {
+		return
+	}
+	s.pruned = now
+
+	for jti, until := range s.seen {
+		if now >= until {
+			delete(s.seen, jti)
+		}
+	}
+}
+
+func (c *ReplayCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// registeredClaims decodes the registered claims from the payload segment
+// of a parsed token, whatever claims type it was created with.
+func registeredClaims(token *Token) (*registered, error) {
+	i := strings.IndexByte(token.HeaderPayload, '.')
+	if i < 0 {
+		return nil, errors.New("The token has not been parsed")
+	}
+
+	seg, err := DecodeSegment(token.HeaderPayload[i+1:])
+	if err != nil {
+		return nil, err
+	}
+	return decodeRegisteredClaims(seg)
+}
@@ -0,0 +1,121 @@
+package gojwt
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReplayCache(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	v := &Verifier{
+		Method:      HS256,
+		KeyFunc:     func(*Token) (interface{}, error) { return testHMACKey, nil },
+		NewClaims:   func() Claims { return &IanaClaims{} },
+		ReplayCache: NewReplayCache(),
+	}
+
+	// A forged token does not burn the jti
+	forged := signTestTokenWith(t, NewToken(HS256, &IanaClaims{Jti: "reset-1", ExpiresAt: exp}),
+		[]byte("another key of thirty two bytes!"))
+	if _, err := v.Verify(forged); err == nil {
+		t.Error("Expected error for forged token")
+	}
+
+	reset := signTestToken(t, &IanaClaims{Jti: "reset-1", ExpiresAt: exp})
+	if _, err := v.Verify(reset); err != nil {
+		t.Fatal(err)
+	}
+	_, err := v.Verify(reset)
+	if e, ok := err.(*TokenError); !ok || e.Flags&ErrorTokenReplayed == 0 {
+		t.Errorf("got '%v' want replayed token error", err)
+	}
+
+	_, err = v.Verify(signTestToken(t, &IanaClaims{ExpiresAt: exp}))
+	if e, ok := err.(*TokenError); !ok || e.Flags&ErrorInvalidJti == 0 {
+		t.Errorf("got '%v' want invalid jti error", err)
+	}
+	if _, err := v.Verify(signTestToken(t, &IanaClaims{Jti: "reset-2"})); err == nil {
+		t.Error("Expected error for token without exp")
+	}
+}
+
+func TestReplayCacheArrayAudience(t *testing.T) {
+	v := &Verifier{
+		Method:      HS256,
+		KeyFunc:     func(*Token) (interface{}, error) { return testHMACKey, nil },
+		NewClaims:   func() Claims { return &IDTokenClaims{} },
+		ReplayCache: NewReplayCache(),
+	}
+
+	token := NewToken(HS256, &IDTokenClaims{
+		IanaClaims: IanaClaims{Jti: "link-1", ExpiresAt: time.Now().Add(time.Hour).Unix()},
+		Audience:   Audience{"client", "api"},
+	})
+	tokenString := signTestTokenWith(t, token, testHMACKey)
+	if _, err := v.Verify(tokenString); err != nil {
+		t.Fatal(err)
+	}
+	_, err := v.Verify(tokenString)
+	if e, ok := err.(*TokenError); !ok || e.Flags&ErrorTokenReplayed == 0 {
+		t.Errorf("got '%v' want replayed token error", err)
+	}
+}
+
+func TestReplayCacheExpiration(t *testing.T) {
+	now := time.Now()
+	c := NewReplayCache()
+	c.now = func() time.Time { return now }
+
+	if !c.Use("a", now.Add(time.Minute)) {
+		t.Error("Expected first use")
+	}
+	if c.Use("a", now.Add(time.Minute)) {
+		t.Error("Expected replay")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !c.Use("a", now.Add(time.Minute)) {
+		t.Error("Expected expired entry to be reusable")
+	}
+	if !c.Use("b", now.Add(time.Minute)) {
+		t.Error("Expected first use")
+	}
+}
+
+func TestReplayCacheConcurrent(t *testing.T) {
+	c := NewReplayCache()
+	exp := time.Now().Add(time.Hour)
+
+	var wg sync.WaitGroup
+	var used int32
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c.Use("once", exp) {
+				atomic.AddInt32(&used, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if used != 1 {
+		t.Errorf("got '%v' want '%v'", used, 1)
+	}
+}
+
+func BenchmarkReplayCache(b *testing.B) {
+	c := NewReplayCache()
+	exp := time.Now().Add(time.Hour)
+	var n int64
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			id := atomic.AddInt64(&n, 1)
+			c.Use(strconv.FormatInt(id, 10), exp)
+		}
+	})
+}
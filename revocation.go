@@ -0,0 +1,285 @@
+package gojwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Revocation is a denylist of tokens consulted by the Parser.
+type Revocation interface {
+	// RevokedJti reports whether the token with the "jti" is revoked.
+	RevokedJti(jti string) (bool, error)
+
+	// RevokedSubject reports whether the tokens of the subject issued at
+	// the "iat" time are revoked. issuedAt is zero when the token has no
+	// "iat".
+	RevokedSubject(subject string, issuedAt int64) (bool, error)
+}
+
+// checkRevocation decodes the registered claims of the payload and checks
+// them against the revocation list. The claims the token was created
+// with may not embed IanaClaims, so they are decoded again.
+func checkRevocation(r Revocation, payload []byte) error {
+	claims, err := decodeRegisteredClaims(payload)
+	if err != nil {
+		return err
+	}
+
+	if claims.Jti != "" {
+		revoked, err := r.RevokedJti(claims.Jti)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return &TokenError{Text: errors.New("Token revoked"), Flags: ErrorInvalidJti | ErrorTokenRevoked}
+		}
+	}
+
+	if claims.Subject != "" {
+		revoked, err := r.RevokedSubject(claims.Subject, claims.IssuedAt)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return &TokenError{Text: errors.New("Token revoked"), Flags: ErrorTokenRevoked}
+		}
+	}
+
+	return nil
+}
+
+// registered holds the registered claims used by the revocation list and
+// the replay cache. "aud" is left out, as it is a string or an array.
+type registered struct {
+	Jti       string `json:"jti"`
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+func decodeRegisteredClaims(payload []byte) (*registered, error) {
+	var claims registered
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, &TokenError{Text: fmt.Errorf("Invalid registered claims: %v", err), Flags: ErrorInvalidClaim}
+	}
+	return &claims, nil
+}
+
+// MemoryRevocation is a Revocation kept in memory. Entries are dropped
+// once the tokens they deny have expired.
+type MemoryRevocation struct {
+	mu       sync.Mutex
+	jtis     map[string]int64
+	subjects map[string]revokedSubject
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+type revokedSubject struct {
+	// Before denies the tokens issued before this time
+	Before int64 `json:"before"`
+
+	// Until is the time at which the entry is dropped
+	Until int64 `json:"until"`
+}
+
+func NewMemoryRevocation() *MemoryRevocation {
+	return &MemoryRevocation{
+		jtis:     make(map[string]int64),
+		subjects: make(map[string]revokedSubject),
+	}
+}
+
+// RevokeJti denies the token with the jti, until its expiration time.
+func (r *MemoryRevocation) RevokeJti(jti string, expiresAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if until := expiresAt.Unix(); until > r.jtis[jti] {
+		r.jtis[jti] = until
+	}
+}
+
+// RevokeSubject denies every token of the subject until the given time,
+// which should be past the expiration of the tokens already issued.
+func (r *MemoryRevocation) RevokeSubject(subject string, until time.Time) {
+	r.revokeSubject(subject, math.MaxInt64, until)
+}
+
+// RevokeIssuedBefore denies the tokens of the subject issued before the
+// given time, e.g. to log a user out everywhere. The entry is kept until
+// the given time, past the expiration of the denied tokens.
+func (r *MemoryRevocation) RevokeIssuedBefore(subject string, before, until time.Time) {
+	r.revokeSubject(subject, before.Unix(), until)
+}
+
+func (r *MemoryRevocation) revokeSubject(subject string, before int64, until time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := r.subjects[subject]
+	if before > entry.Before {
+		entry.Before = before
+	}
+	if u := until.Unix(); u > entry.Until {
+		entry.Until = u
+	}
+	r.subjects[subject] = entry
+}
+
+func (r *MemoryRevocation) RevokedJti(jti string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until, ok := r.jtis[jti]
+	if !ok {
+		return false, nil
+	}
+	if r.clock().Unix() >= until {
+		delete(r.jtis, jti)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *MemoryRevocation) RevokedSubject(subject string, issuedAt int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.subjects[subject]
+	if !ok {
+		return false, nil
+	}
+	if r.clock().Unix() >= entry.Until {
+		delete(r.subjects, subject)
+		return false, nil
+	}
+	return issuedAt < entry.Before, nil
+}
+
+// Prune drops the expired entries.
+func (r *MemoryRevocation) Prune() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.clock().Unix()
+	for jti, until := range r.jtis {
+		if now >= until {
+			delete(r.jtis, jti)
+		}
+	}
+	for subject, entry := range r.subjects {
+		if now >= entry.Until {
+			delete(r.subjects, subject)
+		}
+	}
+}
+
+func (r *MemoryRevocation) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// FileRevocation is a MemoryRevocation saved to a json file after every
+// change, for single node deployments where revocations must survive
+// restarts.
+type FileRevocation struct {
+	*MemoryRevocation
+	path string
+
+	// mu serializes the writes of the file
+	mu sync.Mutex
+}
+
+type revocationFile struct {
+	Jtis     map[string]int64          `json:"jti"`
+	Subjects map[string]revokedSubject `json:"sub"`
+}
+
+// OpenFileRevocation loads the revocation list from the file, which is
+// created on the first change when it does not exist.
+func OpenFileRevocation(path string) (*FileRevocation, error) {
+	r := &FileRevocation{MemoryRevocation: NewMemoryRevocation(), path: path}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f revocationFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("Invalid revocation file: %v", err)
+	}
+	for jti, until := range f.Jtis {
+		r.jtis[jti] = until
+	}
+	for subject, entry := range f.Subjects {
+		r.subjects[subject] = entry
+	}
+	r.Prune()
+	return r, nil
+}
+
+func (r *FileRevocation) RevokeJti(jti string, expiresAt time.Time) error {
+	r.MemoryRevocation.RevokeJti(jti, expiresAt)
+	return r.save()
+}
+
+func (r *FileRevocation) RevokeSubject(subject string, until time.Time) error {
+	r.MemoryRevocation.RevokeSubject(subject, until)
+	return r.save()
+}
+
+func (r *FileRevocation) RevokeIssuedBefore(subject string, before, until time.Time) error {
+	r.MemoryRevocation.RevokeIssuedBefore(subject, before, until)
+	return r.save()
+}
+
+// save writes the list to a temporary file renamed over the file, so that
+// a crash never leaves a truncated list.
+func (r *FileRevocation) save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Prune()
+
+	r.MemoryRevocation.mu.Lock()
+	b, err := json.Marshal(revocationFile{Jtis: r.jtis, Subjects: r.subjects})
+	r.MemoryRevocation.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(r.path), filepath.Base(r.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
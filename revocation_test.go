@@ -0,0 +1,125 @@
+package gojwt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRevocation(t *testing.T) {
+	now := time.Now()
+
+	r := NewMemoryRevocation()
+	r.RevokeJti("stolen", now.Add(time.Hour))
+	r.RevokeSubject("banned", now.Add(time.Hour))
+	r.RevokeIssuedBefore("alice", now, now.Add(time.Hour))
+	p := &Parser{Revocation: r}
+
+	for i, data := range []struct {
+		claims    *IanaClaims
+		wantFlags uint32
+	}{
+		{&IanaClaims{Jti: "valid", Subject: "bob"}, 0},
+		{&IanaClaims{Jti: "stolen", Subject: "bob"}, ErrorInvalidJti | ErrorTokenRevoked},
+		{&IanaClaims{Subject: "banned", IssuedAt: now.Add(time.Minute).Unix()}, ErrorTokenRevoked},
+		{&IanaClaims{Subject: "alice", IssuedAt: now.Add(-time.Minute).Unix()}, ErrorTokenRevoked},
+		{&IanaClaims{Subject: "alice"}, ErrorTokenRevoked},
+		{&IanaClaims{Subject: "alice", IssuedAt: now.Unix()}, 0},
+	} {
+		// The revocation list reads the registered claims of any payload
+		err := p.Parse(NewToken(HS256, &customClaims{}), signTestToken(t, data.claims), false)
+		var got uint32
+		if e, ok := err.(*TokenError); ok {
+			got = e.Flags
+		} else if err != nil {
+			t.Errorf("[%d] %v", i, err)
+		}
+		if got != data.wantFlags {
+			t.Errorf("[%d] got '%v' want '%v'", i, got, data.wantFlags)
+		}
+	}
+
+	// Entries expire with the tokens they deny
+	r.now = func() time.Time { return now.Add(2 * time.Hour) }
+	if revoked, _ := r.RevokedJti("stolen"); revoked {
+		t.Error("Expected expired jti entry")
+	}
+	if revoked, _ := r.RevokedSubject("banned", 0); revoked {
+		t.Error("Expected expired subject entry")
+	}
+	r.Prune()
+	if len(r.jtis) != 0 || len(r.subjects) != 0 {
+		t.Errorf("got '%v' '%v' want empty lists", r.jtis, r.subjects)
+	}
+}
+
+func TestFileRevocation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gojwt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "revoked.json")
+	now := time.Now()
+
+	r, err := OpenFileRevocation(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RevokeJti("stolen", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RevokeJti("expired", now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RevokeIssuedBefore("alice", now, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The list survives a restart
+	r, err = OpenFileRevocation(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := r.RevokedJti("stolen"); !revoked {
+		t.Error("Expected revoked jti")
+	}
+	if revoked, _ := r.RevokedSubject("alice", now.Add(-time.Minute).Unix()); !revoked {
+		t.Error("Expected revoked subject")
+	}
+	if _, ok := r.jtis["expired"]; ok {
+		t.Error("Expected expired entry to be dropped")
+	}
+}
+
+func TestRevocationArrayAudience(t *testing.T) {
+	r := NewMemoryRevocation()
+	r.RevokeJti("stolen", time.Now().Add(time.Hour))
+	p := &Parser{Revocation: r}
+
+	for i, data := range []struct {
+		jti       string
+		wantFlags uint32
+	}{
+		{"valid", 0},
+		{"stolen", ErrorInvalidJti | ErrorTokenRevoked},
+	} {
+		tokenString := signTestToken(t, &IDTokenClaims{
+			IanaClaims: IanaClaims{Jti: data.jti},
+			Audience:   Audience{"client", "api"},
+		})
+
+		err := p.Parse(NewToken(HS256, &IDTokenClaims{}), tokenString, false)
+		var got uint32
+		if e, ok := err.(*TokenError); ok {
+			got = e.Flags
+		} else if err != nil {
+			t.Errorf("[%d] %v", i, err)
+		}
+		if got != data.wantFlags {
+			t.Errorf("[%d] got '%v' want '%v'", i, got, data.wantFlags)
+		}
+	}
+}
@@ -10,23 +10,37 @@ import (
 
 type SignMethodRSA struct {
 	Hash crypto.Hash
+
+	// AllowWeakKeys disables the minimum key size check, for
+	// interoperability with legacy keys smaller than MinRSAKeyBits.
+	AllowWeakKeys bool
 }
 
 func (m SignMethodRSA) Verify(signingString string, signature string, key interface{}) error {
+	pub, err := checkRSAPublicKey(key, m.AllowWeakKeys)
+	if err != nil {
+		return err
+	}
+
 	sig, err := DecodeSegment(signature)
 	if err != nil {
 		return err
 	}
 	h := m.Hash.New()
 	h.Write([]byte(signingString))
-	return rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), m.Hash, h.Sum(nil), sig)
+	return rsa.VerifyPKCS1v15(pub, m.Hash, h.Sum(nil), sig)
 }
 
 func (m SignMethodRSA) Sign(signinString string, key interface{}) (string, error) {
+	pkey, err := checkRSAPrivateKey(key, m.AllowWeakKeys)
+	if err != nil {
+		return "", err
+	}
+
 	h := m.Hash.New()
 	h.Write([]byte(signinString))
 
-	sig, err := rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), m.Hash, h.Sum(nil))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, pkey, m.Hash, h.Sum(nil))
 	if err != nil {
 		return "", err
 	}
@@ -10,9 +10,18 @@ import (
 
 type SignMethodRSAPSS struct {
 	Hash crypto.Hash
+
+	// AllowWeakKeys disables the minimum key size check, for
+	// interoperability with legacy keys smaller than MinRSAKeyBits.
+	AllowWeakKeys bool
 }
 
 func (m SignMethodRSAPSS) Verify(signingString string, signature string, key interface{}) error {
+	pub, err := checkRSAPublicKey(key, m.AllowWeakKeys)
+	if err != nil {
+		return err
+	}
+
 	sig, err := DecodeSegment(signature)
 	if err != nil {
 		return err
@@ -20,7 +29,7 @@ func (m SignMethodRSAPSS) Verify(signingString string, signature string, key int
 	h := m.Hash.New()
 	h.Write([]byte(signingString))
 
-	return rsa.VerifyPSS(key.(*rsa.PublicKey), m.Hash,
+	return rsa.VerifyPSS(pub, m.Hash,
 		h.Sum(nil), sig, &rsa.PSSOptions{
 			Hash:       m.Hash,
 			SaltLength: rsa.PSSSaltLengthEqualsHash,
@@ -28,11 +37,16 @@ func (m SignMethodRSAPSS) Verify(signingString string, signature string, key int
 }
 
 func (m SignMethodRSAPSS) Sign(signinString string, key interface{}) (string, error) {
+	pkey, err := checkRSAPrivateKey(key, m.AllowWeakKeys)
+	if err != nil {
+		return "", err
+	}
+
 	h := m.Hash.New()
 	h.Write([]byte(signinString))
 
 	sig, err := rsa.SignPSS(
-		rand.Reader, key.(*rsa.PrivateKey), m.Hash, h.Sum(nil), &rsa.PSSOptions{
+		rand.Reader, pkey, m.Hash, h.Sum(nil), &rsa.PSSOptions{
 			Hash:       m.Hash,
 			SaltLength: rsa.PSSSaltLengthEqualsHash,
 		})
@@ -1,6 +1,8 @@
 package gojwt
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
 	"errors"
 	"strings"
 	"testing"
@@ -622,3 +624,37 @@ func TestRSAPSS(t *testing.T) {
 		}
 	}
 }
+
+func TestRSAWeakKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []uint{RS256, PS256} {
+		token := NewToken(id, &IanaClaims{})
+		if err := token.Sign(key); err == nil {
+			t.Errorf("[%d] Expected error for 1024 bit RSA key", id)
+		}
+
+		switch m := token.Method.(type) {
+		case SignMethodRSA:
+			m.AllowWeakKeys = true
+			token.Method = m
+		case SignMethodRSAPSS:
+			m.AllowWeakKeys = true
+			token.Method = m
+		}
+		if err := token.Sign(key); err != nil {
+			t.Fatalf("[%d] %v", id, err)
+		}
+		if err := token.Verify(&key.PublicKey); err != nil {
+			t.Errorf("[%d] %v", id, err)
+		}
+
+		token.Method = SignMethodTable[id].Method
+		if err := token.Verify(&key.PublicKey); err == nil {
+			t.Errorf("[%d] Expected error for 1024 bit RSA key", id)
+		}
+	}
+}
@@ -2,48 +2,27 @@ package gojwt
 
 import (
 	"crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
 	"errors"
 	"io/ioutil"
 )
 
 func ParseRSAPrivateKey(key []byte, password []byte) (*rsa.PrivateKey, error) {
 
-	block, _ := pem.Decode(key)
-	if block == nil {
-		return nil, errors.New("Failed to parse PEM block")
-	}
-
-	if len(password) != 0 {
-		der, err := x509.DecryptPEMBlock(block, password)
-		if err != nil {
-			return nil, err
-		}
-		pkey, err := x509.ParsePKCS1PrivateKey(der)
-		if err != nil {
-			return nil, err
-		}
-		return pkey, nil
-	}
-
-	pkey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	pkey, err := ParsePrivateKey(key, password)
 	if err != nil {
 		return nil, err
 	}
 
-	return pkey, nil
+	if pkey, ok := pkey.(*rsa.PrivateKey); ok {
+		return pkey, nil
+	}
 
+	return nil, errors.New("Unknown type of private key")
 }
 
 func ParseRSAPublicKey(key []byte) (*rsa.PublicKey, error) {
 
-	block, _ := pem.Decode(key)
-	if block == nil {
-		return nil, errors.New("Failed to parse PEM block")
-	}
-
-	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	pub, err := ParsePublicKey(key)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,22 @@
+package gojwt
+
+import "testing"
+
+// testHMACKey is the HS256 key shared by the tests.
+var testHMACKey = []byte("01234567890123456789012345678901")
+
+// signTestToken signs the claims with HS256 and testHMACKey and returns the
+// token string.
+func signTestToken(t *testing.T, claims Claims) string {
+	return signTestTokenWith(t, NewToken(HS256, claims), testHMACKey)
+}
+
+// signTestTokenWith signs the token, whose header may have been customized,
+// with key and returns the token string.
+func signTestTokenWith(t *testing.T, token *Token, key interface{}) string {
+	t.Helper()
+	if err := token.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	return token.Value
+}
@@ -0,0 +1,91 @@
+package gojwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Thumbprint computes the JWK thumbprint of the key as defined in RFC 7638,
+// hashing the canonical JSON form of the required key members with the
+// given hash. Private keys are thumbprinted using their public half, so a
+// keypair yields a single identifier. Supported keys are RSA, EC (P-256,
+// P-384, P-521), OKP (Ed25519) and oct ([]byte).
+func Thumbprint(key interface{}, hash crypto.Hash) (string, error) {
+	if !hash.Available() {
+		return "", errors.New("Thumbprint hash function is not available")
+	}
+
+	input, err := thumbprintInput(key)
+	if err != nil {
+		return "", err
+	}
+
+	h := hash.New()
+	h.Write([]byte(input))
+	return EncodeSegment(h.Sum(nil)), nil
+}
+
+// thumbprintInput returns the required members of the JWK in lexicographic
+// order and without whitespace. All values are base64url strings or fixed
+// curve names, hence no escaping is required.
+func thumbprintInput(key interface{}) (string, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return thumbprintInput(&k.PublicKey)
+	case *rsa.PublicKey:
+		return fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`,
+			EncodeSegment(big.NewInt(int64(k.E)).Bytes()),
+			EncodeSegment(k.N.Bytes())), nil
+	case *ecdsa.PrivateKey:
+		return thumbprintInput(&k.PublicKey)
+	case *ecdsa.PublicKey:
+		crv, size, err := curveParams(k)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`,
+			crv,
+			EncodeSegment(padBytes(k.X.Bytes(), size)),
+			EncodeSegment(padBytes(k.Y.Bytes(), size))), nil
+	case ed25519.PrivateKey:
+		return thumbprintInput(k.Public())
+	case ed25519.PublicKey:
+		return fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`,
+			EncodeSegment(k)), nil
+	case []byte:
+		return fmt.Sprintf(`{"k":"%s","kty":"oct"}`, EncodeSegment(k)), nil
+	}
+
+	return "", fmt.Errorf("Unsupported key type for thumbprint: %T", key)
+}
+
+// curveParams returns the JWK curve name and the coordinate size in bytes.
+func curveParams(k *ecdsa.PublicKey) (string, int, error) {
+	if k.Curve == nil {
+		return "", 0, errors.New("Missing elliptic curve")
+	}
+
+	size := (k.Curve.Params().BitSize + 7) / 8
+	switch k.Curve.Params().Name {
+	case "P-256", "P-384", "P-521":
+		return k.Curve.Params().Name, size, nil
+	}
+
+	return "", 0, errors.New("Unsupported elliptic curve: " + k.Curve.Params().Name)
+}
+
+// padBytes left pads b with zeroes up to size bytes.
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+
+	out := make([]byte, size)
+	copy(out[size-len(b):], b)
+	return out
+}
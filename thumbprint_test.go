@@ -0,0 +1,106 @@
+package gojwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"math/big"
+	"testing"
+)
+
+func TestThumbprintRSA(t *testing.T) {
+	// RFC 7638 section 3.1
+	n, err := DecodeSegment("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86z" +
+		"wu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5Js" +
+		"GY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMic" +
+		"AtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-" +
+		"bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csF" +
+		"Cur-kEgU8awapJzKnqDKgw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+	want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"
+	if got, err := Thumbprint(key, crypto.SHA256); err != nil || got != want {
+		t.Errorf("got '%v' want '%v' (%v)", got, want, err)
+	}
+}
+
+func TestThumbprintEd25519(t *testing.T) {
+	// RFC 8037 appendix A.3
+	x, err := DecodeSegment("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"
+	if got, err := Thumbprint(ed25519.PublicKey(x), crypto.SHA256); err != nil || got != want {
+		t.Errorf("got '%v' want '%v' (%v)", got, want, err)
+	}
+}
+
+func TestThumbprintKeypair(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priv, err := Thumbprint(key, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := Thumbprint(&key.PublicKey, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv != pub {
+		t.Errorf("got '%v' want '%v'", priv, pub)
+	}
+
+	input, err := thumbprintInput([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"k":"c2VjcmV0","kty":"oct"}`; input != want {
+		t.Errorf("got '%v' want '%v'", input, want)
+	}
+
+	if _, err := Thumbprint("key", crypto.SHA256); err == nil {
+		t.Error("Expected error for unsupported key type")
+	}
+}
+
+func TestTokenKeyID(t *testing.T) {
+	key, err := ParseRSAPrivateKey([]byte(testVectorRSA[0].pemData), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Thumbprint(key, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := NewToken(RS256, &IanaClaims{})
+	token.KeyIDHash = crypto.SHA256
+	if err := token.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	if got := token.Header["kid"]; got != want {
+		t.Errorf("got '%v' want '%v'", got, want)
+	}
+
+	// An explicit key ID is left untouched
+	token = NewToken(RS256, &IanaClaims{})
+	token.Header["kid"] = "explicit"
+	token.KeyIDHash = crypto.SHA256
+	if err := token.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	if got := token.Header["kid"]; got != "explicit" {
+		t.Errorf("got '%v' want '%v'", got, "explicit")
+	}
+}
@@ -1,6 +1,7 @@
 package gojwt
 
 import (
+	"crypto"
 	"encoding/base64"
 	"encoding/json"
 	"strings"
@@ -13,16 +14,41 @@ type Token struct {
 	Payload       Claims
 	Signature     string
 	HeaderPayload string
+
+	// Unprotected holds the unprotected header parameters of a signature
+	// in the JWS JSON serialization. It is not covered by the signature.
+	Unprotected map[string]interface{}
+
+	// KeyIDHash enables the automatic "kid" header. When set and the
+	// header has no "kid", Sign uses the RFC 7638 thumbprint of the
+	// signing key computed with this hash as the key ID.
+	KeyIDHash crypto.Hash
 }
 
 func NewToken(id uint, claims Claims) *Token {
+	// The header is copied so that per-token parameters do not leak into
+	// the shared SignMethodTable
+	header := make(map[string]interface{}, len(SignMethodTable[id].Header))
+	for k, v := range SignMethodTable[id].Header {
+		header[k] = v
+	}
+
 	return &Token{
 		Method:  SignMethodTable[id].Method,
-		Header:  SignMethodTable[id].Header,
+		Header:  header,
 		Payload: claims,
 	}
 }
 
+// NewTokenWithType returns a token whose "typ" header is typ instead of
+// "JWT", for explicit typing of the different kinds of tokens signed with
+// the same key, e.g. "at+jwt" for access tokens (RFC 9068).
+func NewTokenWithType(id uint, typ string, claims Claims) *Token {
+	t := NewToken(id, claims)
+	t.Header["typ"] = typ
+	return t
+}
+
 func (t *Token) Build() error {
 	var b []byte
 	var err error
@@ -54,6 +80,10 @@ func (t *Token) Parse(tokenString string, validate bool) error {
 func (t *Token) Sign(key interface{}) error {
 	var err error
 
+	if err = t.setKeyID(key); err != nil {
+		return err
+	}
+
 	err = t.Build()
 	if err != nil {
 		return err
@@ -68,6 +98,24 @@ func (t *Token) Sign(key interface{}) error {
 	return nil
 }
 
+// setKeyID sets the "kid" header to the thumbprint of the key when
+// KeyIDHash is set and the header has no "kid".
+func (t *Token) setKeyID(key interface{}) error {
+	if _, ok := t.Header["kid"]; ok || t.KeyIDHash == 0 {
+		return nil
+	}
+
+	kid, err := Thumbprint(key, t.KeyIDHash)
+	if err != nil {
+		return err
+	}
+	if t.Header == nil {
+		t.Header = make(map[string]interface{})
+	}
+	t.Header["kid"] = kid
+	return nil
+}
+
 func (t *Token) Verify(key interface{}) error {
 	return t.Method.Verify(t.HeaderPayload, t.Signature, key)
 }
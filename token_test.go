@@ -15,3 +15,12 @@ func TestToken(t *testing.T) {
 	token.Parse("", false)
 	token.Parse("", true)
 }
+
+func TestNewTokenHeaderCopy(t *testing.T) {
+	token := NewToken(HS256, &IanaClaims{})
+	token.Header["kid"] = "test"
+
+	if _, ok := SignMethodTable[HS256].Header["kid"]; ok {
+		t.Error("Token header leaked into SignMethodTable")
+	}
+}
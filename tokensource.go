@@ -0,0 +1,157 @@
+package gojwt
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TokenSource mints short-lived self-signed tokens for service to service
+// calls, one per audience. Tokens are cached until RefreshBefore their
+// expiration; concurrent callers for the same audience wait for a single
+// token to be minted.
+type TokenSource struct {
+	// Method is the signing method (HS256, RS256, ...) and Key the
+	// signing key.
+	Method uint
+	Key    interface{}
+
+	Issuer  string
+	Subject string
+
+	// Lifetime of the minted tokens, 5 minutes when zero.
+	Lifetime time.Duration
+
+	// RefreshBefore is how long before expiration a cached token is
+	// replaced, a fifth of the lifetime when zero.
+	RefreshBefore time.Duration
+
+	// Claims returns the claims of a token from the registered claims
+	// filled in by the source, to add private claims. Optional.
+	Claims func(claims IanaClaims) Claims
+
+	mu    sync.Mutex
+	cache map[string]*cachedToken
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+type cachedToken struct {
+	mu        sync.Mutex
+	value     string
+	expiresAt time.Time
+}
+
+// Token returns a valid token for the audience, minting a new one when the
+// cached token is missing or close to expiration.
+func (s *TokenSource) Token(audience string) (string, error) {
+	s.mu.Lock()
+	if s.cache == nil {
+		s.cache = make(map[string]*cachedToken)
+	}
+	entry, ok := s.cache[audience]
+	if !ok {
+		entry = &cachedToken{}
+		s.cache[audience] = entry
+	}
+	s.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	now := s.clock()
+	if entry.value != "" && now.Before(entry.expiresAt.Add(-s.refreshBefore())) {
+		return entry.value, nil
+	}
+
+	value, expiresAt, err := s.mint(audience, now)
+	if err != nil {
+		return "", err
+	}
+	entry.value = value
+	entry.expiresAt = expiresAt
+	return value, nil
+}
+
+func (s *TokenSource) mint(audience string, now time.Time) (string, time.Time, error) {
+	lifetime := s.Lifetime
+	if lifetime == 0 {
+		lifetime = 5 * time.Minute
+	}
+	expiresAt := now.Add(lifetime)
+
+	iana := IanaClaims{
+		Issuer:    s.Issuer,
+		Subject:   s.Subject,
+		Audience:  audience,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	}
+
+	var claims Claims = &iana
+	if s.Claims != nil {
+		claims = s.Claims(iana)
+	}
+
+	token := NewToken(s.Method, claims)
+	if err := token.Sign(s.Key); err != nil {
+		return "", time.Time{}, err
+	}
+	return token.Value, expiresAt, nil
+}
+
+func (s *TokenSource) refreshBefore() time.Duration {
+	if s.RefreshBefore != 0 {
+		return s.RefreshBefore
+	}
+	if s.Lifetime != 0 {
+		return s.Lifetime / 5
+	}
+	return time.Minute
+}
+
+func (s *TokenSource) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// Transport is an http.RoundTripper adding a bearer token from the source
+// to every request.
+type Transport struct {
+	Source *TokenSource
+
+	// Audience of the tokens, the scheme and host of the request URL
+	// when empty.
+	Audience string
+
+	// Base defaults to http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	audience := t.Audience
+	if audience == "" {
+		audience = r.URL.Scheme + "://" + r.URL.Host
+	}
+
+	token, err := t.Source.Token(audience)
+	if err != nil {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		return nil, err
+	}
+
+	// A RoundTripper must not modify the request
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+token)
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(r)
+}
@@ -0,0 +1,124 @@
+package gojwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenSource(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	var minted int32
+
+	s := &TokenSource{
+		Method:   HS256,
+		Key:      testHMACKey,
+		Issuer:   "orders",
+		Lifetime: 5 * time.Minute,
+		Claims: func(claims IanaClaims) Claims {
+			atomic.AddInt32(&minted, 1)
+			return &claims
+		},
+		now: func() time.Time { return now },
+	}
+
+	first, err := s.Token("billing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := &IanaClaims{}
+	token := NewToken(HS256, claims)
+	if err := token.Parse(first, false); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Audience != "billing" || claims.Issuer != "orders" || claims.ExpiresAt != now.Add(5*time.Minute).Unix() {
+		t.Errorf("got '%+v'", claims)
+	}
+
+	// Cached until a fifth of the lifetime before expiration
+	now = now.Add(3 * time.Minute)
+	if got, _ := s.Token("billing"); got != first {
+		t.Error("Expected cached token")
+	}
+	now = now.Add(time.Minute + time.Second)
+	if got, _ := s.Token("billing"); got == first {
+		t.Error("Expected refreshed token")
+	}
+
+	// Tokens are per audience
+	if got, _ := s.Token("shipping"); got == first {
+		t.Error("Expected token for another audience")
+	}
+	if got := atomic.LoadInt32(&minted); got != 3 {
+		t.Errorf("got '%v' want '%v'", got, 3)
+	}
+}
+
+func TestTokenSourceConcurrent(t *testing.T) {
+	var minted int32
+	s := &TokenSource{
+		Method: HS256,
+		Key:    testHMACKey,
+		Claims: func(claims IanaClaims) Claims {
+			atomic.AddInt32(&minted, 1)
+			time.Sleep(10 * time.Millisecond)
+			return &claims
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Token("billing"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&minted); got != 1 {
+		t.Errorf("got '%v' want '%v'", got, 1)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var audience string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenString, err := BearerExtractor.Extract(r)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		claims := &IanaClaims{}
+		token := NewToken(HS256, claims)
+		if err := token.Parse(tokenString, true); err != nil {
+			t.Error(err)
+		}
+		if err := token.Verify(testHMACKey); err != nil {
+			t.Error(err)
+		}
+		audience = claims.Audience
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{Source: &TokenSource{Method: HS256, Key: testHMACKey}}}
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if audience != srv.URL {
+		t.Errorf("got '%v' want '%v'", audience, srv.URL)
+	}
+	if req.Header.Get("Authorization") != "" {
+		t.Error("The request was modified")
+	}
+}
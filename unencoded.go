@@ -0,0 +1,109 @@
+package gojwt
+
+import (
+	"errors"
+	"strings"
+)
+
+// SetUnencodedPayload sets the "b64" header to false and lists it in
+// "crit", so that the payload is signed as is instead of base64url encoded
+// (RFC 7797). It applies to SignUnencoded and to detached payloads.
+func (t *Token) SetUnencodedPayload() {
+	if t.Header == nil {
+		t.Header = make(map[string]interface{})
+	}
+	t.Header["b64"] = false
+
+	if critContains(t.Header, "b64") {
+		return
+	}
+	crit, _ := t.Header["crit"].([]interface{})
+	t.Header["crit"] = append(crit, "b64")
+}
+
+// SignUnencoded signs the payload bytes with an unencoded payload and
+// builds a compact token carrying the payload as is. The payload must not
+// contain '.', use SignDetached for such payloads.
+func (t *Token) SignUnencoded(payload []byte, key interface{}) error {
+	if strings.IndexByte(string(payload), '.') >= 0 {
+		return errors.New("An unencoded payload cannot contain '.' in compact form")
+	}
+
+	t.SetUnencodedPayload()
+	if err := t.SignDetached(payload, key); err != nil {
+		return err
+	}
+
+	parts := strings.Split(t.Value, ".")
+	t.Value = parts[0] + "." + string(payload) + "." + parts[2]
+	return nil
+}
+
+// VerifyUnencoded verifies a compact token with an unencoded payload and
+// returns the payload. The "b64" header must be false and listed in
+// "crit".
+func (t *Token) VerifyUnencoded(tokenString string, key interface{}) ([]byte, error) {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("The token does not have 3 segments")
+	}
+
+	header, err := decodeHeader(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	if encoded, err := payloadEncoded(header); err != nil {
+		return nil, err
+	} else if encoded {
+		return nil, errors.New("The token payload is base64url encoded")
+	}
+
+	payload := []byte(parts[1])
+	if err := t.VerifyDetached(parts[0]+".."+parts[2], payload, key); err != nil {
+		return nil, err
+	}
+
+	t.Value = tokenString
+	return payload, nil
+}
+
+// payloadEncoded reports whether the payload is base64url encoded
+// according to the "b64" header, which must be listed in "crit" when
+// false.
+func payloadEncoded(header map[string]interface{}) (bool, error) {
+	v, ok := header["b64"]
+	if !ok {
+		return true, nil
+	}
+
+	b64, ok := v.(bool)
+	if !ok {
+		return false, errors.New("Invalid b64 header")
+	}
+	if !b64 && !critContains(header, "b64") {
+		return false, errors.New("The b64 header must be listed in crit")
+	}
+	return b64, nil
+}
+
+// payloadSegment returns the payload as it appears in the signing input.
+func payloadSegment(header map[string]interface{}, payload []byte) (string, error) {
+	encoded, err := payloadEncoded(header)
+	if err != nil {
+		return "", err
+	}
+	if !encoded {
+		return string(payload), nil
+	}
+	return EncodeSegment(payload), nil
+}
+
+func critContains(header map[string]interface{}, name string) bool {
+	crit, _ := header["crit"].([]interface{})
+	for _, v := range crit {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package gojwt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnencodedPayload(t *testing.T) {
+	// RFC 7797 section 4.2
+	key, _ := DecodeSegment("AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow")
+	payload := []byte("$.02")
+	want := "eyJhbGciOiJIUzI1NiIsImI2NCI6ZmFsc2UsImNyaXQiOlsiYjY0Il19..A5dxf2s96_n5FLueVuW1Z_vh161FwXZC4YLPff6dmDY"
+
+	token := NewToken(HS256, nil)
+	delete(token.Header, "typ")
+	token.SetUnencodedPayload()
+	if err := token.SignDetached(payload, key); err != nil {
+		t.Fatal(err)
+	}
+	if token.Value != want {
+		t.Errorf("got '%v' want '%v'", token.Value, want)
+	}
+
+	if err := NewToken(HS256, nil).VerifyDetached(want, payload, key); err != nil {
+		t.Error(err)
+	}
+
+	// The payload contains '.' and cannot be attached
+	if err := NewToken(HS256, nil).SignUnencoded(payload, key); err == nil {
+		t.Error("Expected error for '.' in unencoded payload")
+	}
+}
+
+func TestSignUnencoded(t *testing.T) {
+	key := testHMACKey
+	payload := []byte(`{"amount":"100"}`)
+
+	token := NewToken(HS256, nil)
+	if err := token.SignUnencoded(payload, key); err != nil {
+		t.Fatal(err)
+	}
+	if parts := strings.Split(token.Value, "."); parts[1] != string(payload) {
+		t.Errorf("got '%v' want '%s'", parts[1], payload)
+	}
+
+	got, err := NewToken(HS256, nil).VerifyUnencoded(token.Value, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(payload) {
+		t.Errorf("got '%s' want '%s'", got, payload)
+	}
+
+	// The regular parser refuses unencoded payloads
+	if err := NewToken(HS256, &IanaClaims{}).Parse(token.Value, false); err == nil {
+		t.Error("Expected error for unencoded payload")
+	}
+
+	// b64 must be listed in crit
+	header := EncodeSegment([]byte(`{"alg":"HS256","b64":false}`))
+	parts := strings.Split(token.Value, ".")
+	if _, err := NewToken(HS256, nil).VerifyUnencoded(header+"."+parts[1]+"."+parts[2], key); err == nil {
+		t.Error("Expected error for b64 missing from crit")
+	}
+
+	// An encoded token is not mistaken for an unencoded one
+	encoded := NewToken(HS256, &IanaClaims{})
+	if err := encoded.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewToken(HS256, nil).VerifyUnencoded(encoded.Value, key); err == nil {
+		t.Error("Expected error for encoded payload")
+	}
+}
@@ -0,0 +1,167 @@
+package gojwt
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CertificateVerifier resolves the verification key of a token from the
+// certificate chain embedded in its "x5c" header (RFC 7515 section 4.1.6).
+type CertificateVerifier struct {
+	// Roots is the set of trusted root certificates the chain must
+	// lead to. It is required: the system roots are never trusted to
+	// issue signing certificates.
+	Roots *x509.CertPool
+
+	// CurrentTime is the time at which the chain is validated. The
+	// current system time is used when zero.
+	CurrentTime time.Time
+
+	// KeyUsages lists the accepted extended key usages of the leaf
+	// certificate. Only server authentication is accepted when empty,
+	// as with x509.VerifyOptions.
+	KeyUsages []x509.ExtKeyUsage
+
+	// AnyKeyUsage accepts a leaf certificate whatever its extended key
+	// usages. KeyUsages is ignored when set.
+	AnyKeyUsage bool
+}
+
+// SetCertificateChain embeds the certificate chain in the "x5c" header and
+// sets the "x5t#S256" thumbprint of the leaf certificate. The leaf must be
+// the first certificate and hold the public half of the signing key.
+func (t *Token) SetCertificateChain(chain []*x509.Certificate) {
+	// Stored the way json decodes it so parsed and built tokens are
+	// handled alike
+	x5c := make([]interface{}, len(chain))
+	for i, cert := range chain {
+		x5c[i] = base64.StdEncoding.EncodeToString(cert.Raw)
+	}
+
+	if t.Header == nil {
+		t.Header = make(map[string]interface{})
+	}
+	t.Header["x5c"] = x5c
+	if len(chain) > 0 {
+		sum := sha256.Sum256(chain[0].Raw)
+		t.Header["x5t#S256"] = EncodeSegment(sum[:])
+	}
+}
+
+// CertificateChain decodes the certificates found in the "x5c" header.
+func (t *Token) CertificateChain() ([]*x509.Certificate, error) {
+	values, ok := t.Header["x5c"].([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, errors.New("Missing or invalid x5c header")
+	}
+
+	chain := make([]*x509.Certificate, len(values))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.New("Invalid x5c header value")
+		}
+		der, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid x5c certificate encoding: %v", err)
+		}
+		chain[i], err = x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid x5c certificate: %v", err)
+		}
+	}
+
+	return chain, nil
+}
+
+// ResolveKey validates the certificate chain found in the "x5c" header
+// against the configured roots, checks the "x5t" and "x5t#S256"
+// thumbprints when present and returns the public key of the leaf
+// certificate.
+func (v *CertificateVerifier) ResolveKey(t *Token) (interface{}, error) {
+	if v.Roots == nil {
+		return nil, errors.New("Missing trusted roots for x5c certificate chain")
+	}
+
+	chain, err := t.CertificateChain()
+	if err != nil {
+		return nil, err
+	}
+	leaf := chain[0]
+
+	if err := verifyThumbprint(t.Header, "x5t", sha1Sum(leaf.Raw)); err != nil {
+		return nil, err
+	}
+	if err := verifyThumbprint(t.Header, "x5t#S256", sha256Sum(leaf.Raw)); err != nil {
+		return nil, err
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         v.Roots,
+		Intermediates: x509.NewCertPool(),
+		CurrentTime:   v.CurrentTime,
+		KeyUsages:     v.KeyUsages,
+	}
+	if v.AnyKeyUsage {
+		opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+	for _, cert := range chain[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	if _, err := leaf.Verify(opts); err != nil {
+		return nil, fmt.Errorf("Invalid x5c certificate chain: %v", err)
+	}
+
+	return leaf.PublicKey, nil
+}
+
+// VerifyCertificateChain verifies the token signature using the key of the
+// leaf certificate in the "x5c" header, once the chain has been validated
+// by the given verifier.
+func (t *Token) VerifyCertificateChain(v *CertificateVerifier) error {
+	key, err := v.ResolveKey(t)
+	if err != nil {
+		return err
+	}
+
+	return t.Verify(key)
+}
+
+func verifyThumbprint(header map[string]interface{}, name string, sum []byte) error {
+	v, ok := header[name]
+	if !ok {
+		return nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("Invalid %s header", name)
+	}
+	got, err := DecodeSegment(s)
+	if err != nil {
+		return fmt.Errorf("Invalid %s header: %v", name, err)
+	}
+
+	if subtle.ConstantTimeCompare(got, sum) != 1 {
+		return fmt.Errorf("Certificate thumbprint mismatch in %s header", name)
+	}
+
+	return nil
+}
+
+func sha1Sum(data []byte) []byte {
+	sum := sha1.Sum(data)
+	return sum[:]
+}
+
+func sha256Sum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
@@ -0,0 +1,163 @@
+package gojwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func newTestCertificate(t *testing.T, cn string, pub crypto.PublicKey, parent *x509.Certificate, parentKey crypto.Signer, usages ...x509.ExtKeyUsage) *x509.Certificate {
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+		ExtKeyUsage:           usages,
+	}
+	if parent == nil {
+		parent = tmpl
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func newTestChain(t *testing.T) ([]*x509.Certificate, *x509.CertPool, interface{}) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := newTestCertificate(t, "gojwt root", &caKey.PublicKey, nil, caKey)
+
+	key, err := ParseRSAPrivateKey([]byte(testVectorRSA[0].pemData), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := newTestCertificate(t, "gojwt leaf", &key.PublicKey, ca, caKey)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	return []*x509.Certificate{leaf, ca}, roots, key
+}
+
+func TestCertificateChain(t *testing.T) {
+	chain, roots, key := newTestChain(t)
+
+	token := NewToken(RS256, &IanaClaims{Subject: "1234567890"})
+	token.SetCertificateChain(chain)
+	if err := token.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := NewToken(RS256, &IanaClaims{})
+	if err := parsed.Parse(token.Value, true); err != nil {
+		t.Fatal(err)
+	}
+
+	v := &CertificateVerifier{
+		Roots:       roots,
+		CurrentTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := parsed.VerifyCertificateChain(v); err != nil {
+		t.Error(err)
+	}
+
+	// The chain is no longer valid once the certificates expire
+	v.CurrentTime = time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := parsed.VerifyCertificateChain(v); err == nil {
+		t.Error("Expected error for expired certificate chain")
+	}
+
+	// Untrusted root
+	v = &CertificateVerifier{
+		Roots:       x509.NewCertPool(),
+		CurrentTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := parsed.VerifyCertificateChain(v); err == nil {
+		t.Error("Expected error for untrusted certificate chain")
+	}
+
+	// The system roots are never used
+	v = &CertificateVerifier{CurrentTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if err := parsed.VerifyCertificateChain(v); err == nil {
+		t.Error("Expected error for missing roots")
+	}
+}
+
+func TestCertificateKeyUsages(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := newTestCertificate(t, "gojwt root", &caKey.PublicKey, nil, caKey)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := newTestCertificate(t, "gojwt leaf", &key.PublicKey, ca, caKey, x509.ExtKeyUsageClientAuth)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	token := NewToken(RS256, &IanaClaims{})
+	token.SetCertificateChain([]*x509.Certificate{leaf, ca})
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, data := range []struct {
+		v    *CertificateVerifier
+		want bool
+	}{
+		{&CertificateVerifier{Roots: roots, CurrentTime: now}, false},
+		{&CertificateVerifier{Roots: roots, CurrentTime: now, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}}, false},
+		{&CertificateVerifier{Roots: roots, CurrentTime: now, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}, true},
+		{&CertificateVerifier{Roots: roots, CurrentTime: now, AnyKeyUsage: true}, true},
+	} {
+		_, err := data.v.ResolveKey(token)
+		if got := err == nil; got != data.want {
+			t.Errorf("[%d] got '%v' want '%v' (%v)", i, got, data.want, err)
+		}
+	}
+}
+
+func TestCertificateThumbprint(t *testing.T) {
+	chain, roots, _ := newTestChain(t)
+
+	token := NewToken(RS256, &IanaClaims{})
+	token.SetCertificateChain(chain)
+
+	v := &CertificateVerifier{
+		Roots:       roots,
+		CurrentTime: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	token.Header["x5t"] = EncodeSegment(sha1Sum(chain[0].Raw))
+	if _, err := v.ResolveKey(token); err != nil {
+		t.Error(err)
+	}
+
+	token.Header["x5t"] = EncodeSegment(sha1Sum(chain[1].Raw))
+	if _, err := v.ResolveKey(token); err == nil {
+		t.Error("Expected error for x5t mismatch")
+	}
+
+	delete(token.Header, "x5t")
+	token.Header["x5t#S256"] = EncodeSegment(sha256Sum(chain[1].Raw))
+	if _, err := v.ResolveKey(token); err == nil {
+		t.Error("Expected error for x5t#S256 mismatch")
+	}
+}